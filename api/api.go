@@ -0,0 +1,38 @@
+// Package api - тела запросов и ответов сервиса без зависимостей от базы, общие для сервиса и client
+package api
+
+// Pagination - информация о странице для ответа списка
+type Pagination struct {
+	Total      *int    `json:"total,omitempty"`
+	Page       int     `json:"page,omitempty"`
+	Limit      int     `json:"limit"`
+	Offset     int     `json:"offset"`
+	NextPage   *int    `json:"next_page"`
+	NextOffset *int    `json:"next_offset"`
+	NextCursor *string `json:"next_cursor,omitempty"`
+}
+
+// File - файл из b_file, src - полный путь к файлу
+type File struct {
+	ID           uint64 `json:"id"`
+	Src          string `json:"src"`
+	SubDir       string `json:"subdir"`
+	FileName     string `json:"file_name"`
+	OriginalName string `json:"original_name"`
+	ContentType  string `json:"content_type"`
+	FileSize     int64  `json:"file_size"`
+	Width        int64  `json:"width"`
+	Height       int64  `json:"height"`
+	Description  string `json:"description"`
+}
+
+// PathSection - раздел в хлебных крошках
+type PathSection struct {
+	ID             uint64 `json:"id"`
+	Code           string `json:"code"`
+	XMLID          string `json:"xml_id"`
+	Name           string `json:"name"`
+	IblockID       uint64 `json:"iblock_id"`
+	DepthLevel     uint64 `json:"depth_level"`
+	SectionPageURL string `json:"section_page_url"`
+}
@@ -0,0 +1,61 @@
+package api
+
+// Basket - запись корзины
+type Basket struct {
+	BasePrice       float64 `json:"base_price"`
+	CanBuy          bool    `json:"can_buy"`
+	Currency        string  `json:"currency"`
+	CustomPrice     bool    `json:"custom_price"`
+	DateInsert      string  `json:"date_insert"`
+	Delay           bool    `json:"delay"`
+	DetailPageURL   string  `json:"detail_page_url"`
+	Discount        float64 `json:"discont"`
+	FuserID         int     `json:"fuser_id"`
+	ID              int     `json:"id"`
+	SectionName     string  `json:"section_name"`
+	Name            string  `json:"name"`
+	Notes           string  `json:"notes"`
+	OrderID         int64   `json:"order_id"`
+	Price           float64 `json:"price"`
+	PriceTypeID     int     `json:"price_type_id"`
+	ProductID       int     `json:"product_id"`
+	Quantity        float64 `json:"quantity"`
+	MeasureRatio    float64 `json:"measure_ratio"`
+	Reserved        bool    `json:"reserved"`
+	ReserveQuantity int64   `json:"reserved_quantity"`
+	Sort            int     `json:"sort"`
+	VatIncluded     bool    `json:"vat_include"`
+	VatRate         float64 `json:"vat_rate"`
+	Weight          float64 `json:"weight"`
+}
+
+// Change - тело запроса на добавление или изменение позиции корзины
+type Change struct {
+	ProductID int     `json:"product_id"`
+	Quantity  float64 `json:"quantity"`
+	Delay     *bool   `json:"delay"`
+}
+
+// Summary - итоги корзины в одной валюте, InvalidQuantity - товары, количество которых не кратно
+// коэффициенту единицы измерения
+type Summary struct {
+	Currency        string       `json:"currency"`
+	Subtotal        float64      `json:"subtotal"`
+	BaseTotal       float64      `json:"base_total"`
+	DiscountTotal   float64      `json:"discount_total"`
+	VatTotal        float64      `json:"vat_total"`
+	Total           float64      `json:"total"`
+	Vat             []*VatAmount `json:"vat"`
+	Weight          float64      `json:"weight"`
+	Quantity        float64      `json:"quantity"`
+	Count           int          `json:"count"`
+	InvalidQuantity []int        `json:"invalid_quantity"`
+}
+
+// VatAmount - сумма НДС по одной ставке
+type VatAmount struct {
+	Rate     float64 `json:"rate"`
+	Included bool    `json:"included"`
+	Base     float64 `json:"base"`
+	Amount   float64 `json:"amount"`
+}
@@ -0,0 +1,125 @@
+package api
+
+// Catalog - товар каталога с ценами, единицей измерения и остатками
+type Catalog struct {
+	ID               uint32             `json:"id"`
+	Avaliable        bool               `json:"available"`
+	Quantity         int64              `json:"quantity"`
+	QuantityReserved int64              `json:"quantity_reserved"`
+	Weight           float64            `json:"weight"`
+	Width            float64            `json:"width"`
+	Length           float64            `json:"length"`
+	Height           float64            `json:"height"`
+	MeasureID        int64              `json:"measure_id"`
+	Measure          Measure            `json:"measure"`
+	Ratio            float64            `json:"ratio"`
+	Type             string             `json:"type"`
+	VatIncluded      bool               `json:"vat_included"`
+	PriceType        string             `json:"price_type"`
+	WithoutOrder     bool               `json:"without_order"`
+	SelectBestPrice  bool               `json:"select_best_price"`
+	Price            float64            `json:"price"`
+	Currency         string             `json:"currency"`
+	Vat              float64            `json:"vat"`
+	Prices           map[string][]Price `json:"prices"`
+	Offers           []uint32           `json:"offers"`
+	Stores           []StoreAmount      `json:"stores,omitempty"`
+}
+
+// ProductsRequest - тело запроса по нескольким товарам
+type ProductsRequest struct {
+	ProductIDs []uint32 `json:"product_ids"`
+}
+
+// BulkInfo - ответ по нескольким товарам: найденные по ID и ID, которых нет в каталоге
+type BulkInfo struct {
+	Items    map[uint32]*Catalog `json:"items"`
+	NotFound []uint32            `json:"not_found"`
+}
+
+// Measure - единица измерения
+type Measure struct {
+	ID         int64  `json:"id"`
+	Code       int64  `json:"code"`
+	Title      string `json:"title"`
+	Symbol     string `json:"symbol"`
+	SymbolIntl string `json:"symbol_intl"`
+}
+
+// Price - цена товара по типу цены
+type Price struct {
+	ID             uint32  `json:"id"`
+	ProductID      uint32  `json:"product_id"`
+	CatalogGroupID uint32  `json:"catalog_group_id"`
+	Price          float64 `json:"price"`
+	Currency       string  `json:"currency"`
+	QuantityFrom   int64   `json:"quantity_from"`
+	QuantityTo     int64   `json:"quantity_to"`
+	Code           string  `json:"code"`
+	Base           bool    `json:"base"`
+	CanBuy         bool    `json:"can_buy"`
+}
+
+// Offer - торговое предложение со значениями свойств дерева, у множественного свойства значений несколько
+type Offer struct {
+	ID      uint64                  `json:"id"`
+	Name    string                  `json:"name"`
+	Code    string                  `json:"code"`
+	XMLID   string                  `json:"xml_id"`
+	Active  bool                    `json:"active"`
+	Sort    uint64                  `json:"sort"`
+	Catalog *Catalog                `json:"catalog"`
+	Props   map[string][]*TreeValue `json:"props"`
+}
+
+// TreeValue - значение свойства дерева предложений
+type TreeValue struct {
+	ID    uint64 `json:"id"`
+	Value string `json:"value"`
+	XMLID string `json:"xml_id"`
+}
+
+// TreeProperty - свойство дерева предложений со всеми значениями
+type TreeProperty struct {
+	ID     uint64       `json:"id"`
+	Code   string       `json:"code"`
+	Name   string       `json:"name"`
+	Values []*TreeValue `json:"values"`
+}
+
+// Combination - сочетание значений свойств дерева, которое ведет на предложение
+type Combination struct {
+	OfferID   uint64              `json:"offer_id"`
+	Values    map[string][]string `json:"values"`
+	Available bool                `json:"available"`
+}
+
+// Offers - предложения продукта с деревом свойств и матрицей сочетаний
+type Offers struct {
+	ProductID    uint32          `json:"product_id"`
+	Items        []*Offer        `json:"items"`
+	Tree         []*TreeProperty `json:"tree"`
+	Combinations []*Combination  `json:"combinations"`
+}
+
+// Store - склад
+type Store struct {
+	ID          uint32 `json:"id"`
+	Code        string `json:"code"`
+	XMLID       string `json:"xml_id"`
+	Title       string `json:"title"`
+	Address     string `json:"address"`
+	Description string `json:"description"`
+	Latitude    string `json:"latitude"`
+	Longitude   string `json:"longitude"`
+	Phone       string `json:"phone"`
+	Email       string `json:"email"`
+	Schedule    string `json:"schedule"`
+	Sort        int    `json:"sort"`
+}
+
+// StoreAmount - остаток товара на складе
+type StoreAmount struct {
+	Store
+	Amount float64 `json:"amount"`
+}
@@ -0,0 +1,28 @@
+package api
+
+// DeliveryRequest - тело запроса на расчет доставки
+type DeliveryRequest struct {
+	FuserID uint32         `json:"fuser_id"`
+	Items   []DeliveryItem `json:"items"`
+}
+
+// DeliveryItem - товар для расчета, если расчет идет не по корзине
+type DeliveryItem struct {
+	ProductID uint32  `json:"product_id"`
+	Quantity  float64 `json:"quantity"`
+}
+
+// DeliveryResult - результат расчета доставки
+type DeliveryResult struct {
+	Zone         string  `json:"zone"`
+	ZoneName     string  `json:"zone_name"`
+	Price        float64 `json:"price"`
+	Currency     string  `json:"currency"`
+	Cost         float64 `json:"cost"`
+	Weight       float64 `json:"weight"`
+	VolumeWeight float64 `json:"volume_weight"`
+	DaysMin      int     `json:"days_min"`
+	DaysMax      int     `json:"days_max"`
+	DateMin      string  `json:"date_min"`
+	DateMax      string  `json:"date_max"`
+}
@@ -0,0 +1,136 @@
+package api
+
+// Element - элемент инфоблока
+type Element struct {
+	ID                uint64               `json:"id"`
+	Code              string               `json:"code"`
+	Name              string               `json:"name"`
+	PreviewPicture    *File                `json:"preview_picture"`
+	DetailPicture     *File                `json:"detail_picture"`
+	PreviewText       string               `json:"preview_text"`
+	DetailText        string               `json:"detail_text"`
+	XMLID             string               `json:"xml_id"`
+	IblockID          uint64               `json:"iblock_id"`
+	IblockSectionID   int64                `json:"iblock_section_id"`
+	Active            bool                 `json:"active"`
+	ActiveFrom        string               `json:"active_from"`
+	ActiveTo          string               `json:"active_to"`
+	Sort              uint64               `json:"sort"`
+	SearchableContent string               `json:"searchable_content"`
+	DateCreate        string               `json:"date_create"`
+	CreatedBy         uint64               `json:"created_by"`
+	TimestampX        string               `json:"timestamp_x"`
+	ModifiedBy        int64                `json:"modified_by"`
+	ShowCounter       int64                `json:"show_counter"`
+	Meta              map[string]string    `json:"meta"`
+	Props             map[string]*Property `json:"properties"`
+	Section           *ElementSection      `json:"section"`
+	Catalog           *Catalog             `json:"catalog"`
+	Prices            []Price              `json:"prices"`
+	DetailPageURL     string               `json:"detail_page_url"`
+	ListPageURL       string               `json:"list_page_url"`
+}
+
+// ElementSection - основной раздел элемента
+type ElementSection struct {
+	ID              uint64 `json:"id"`
+	Code            string `json:"code"`
+	XMLID           string `json:"xml_id"`
+	Name            string `json:"name"`
+	IblockSectionID int64  `json:"iblock_section_id"`
+	DepthLevel      uint64 `json:"depth_level"`
+}
+
+// Property - свойство элемента со значением, для множественных Value и Description - массивы
+type Property struct {
+	ID          uint64      `json:"id"`
+	Code        string      `json:"code"`
+	Name        string      `json:"name"`
+	Type        string      `json:"type"`
+	UserType    string      `json:"user_type"`
+	Multiple    bool        `json:"multiple"`
+	Value       interface{} `json:"value"`
+	Description interface{} `json:"description"`
+}
+
+// ElementList - ответ списка элементов со страницей
+type ElementList struct {
+	Items []*Element `json:"items"`
+	Pagination
+}
+
+// ElementPath - хлебные крошки элемента и ссылка на элемент
+type ElementPath struct {
+	Items         []PathSection `json:"items"`
+	DetailPageURL string        `json:"detail_page_url"`
+}
+
+// Section - раздел инфоблока
+type Section struct {
+	ID                uint64            `json:"id"`
+	Code              string            `json:"code"`
+	Name              string            `json:"name"`
+	Picture           *File             `json:"picture"`
+	Description       string            `json:"description"`
+	XMLID             string            `json:"xml_id"`
+	IblockID          uint64            `json:"iblock_id"`
+	IblockSectionID   int64             `json:"iblock_section_id"`
+	Active            bool              `json:"active"`
+	Sort              uint64            `json:"sort"`
+	DepthLevel        uint64            `json:"depth_level"`
+	SearchableContent string            `json:"searchable_content"`
+	DateCreate        string            `json:"date_create"`
+	CreatedBy         uint64            `json:"created_by"`
+	TimestampX        string            `json:"timestamp_x"`
+	ModifiedBy        int64             `json:"modified_by"`
+	Elements          []uint64          `json:"elements"`
+	Meta              map[string]string `json:"meta"`
+	Props             map[string]string `json:"props"`
+	Parent            *SectionParent    `json:"section"`
+	SectionPageURL    string            `json:"section_page_url"`
+	ListPageURL       string            `json:"list_page_url"`
+}
+
+// SectionParent - родительский раздел
+type SectionParent struct {
+	ID              uint64 `json:"id"`
+	Code            string `json:"code"`
+	XMLID           string `json:"xml_id"`
+	Name            string `json:"name"`
+	IblockSectionID int64  `json:"iblock_section_id"`
+	DepthLevel      uint64 `json:"depth_level"`
+}
+
+// SectionList - ответ списка разделов со страницей
+type SectionList struct {
+	Items []*Section `json:"items"`
+	Pagination
+}
+
+// SectionPath - хлебные крошки раздела и ссылка на раздел
+type SectionPath struct {
+	Items          []PathSection `json:"items"`
+	SectionPageURL string        `json:"section_page_url"`
+}
+
+// TreeQuery - параметры дерева разделов: инфоблок или корневой раздел, глубина от корня (0 - вся),
+// только активные с учетом родителей (GLOBAL_ACTIVE)
+type TreeQuery struct {
+	IblockID   uint64
+	RootID     uint64
+	Depth      uint64
+	ActiveOnly bool
+}
+
+// TreeNode - раздел в дереве с подразделами в порядке сортировки
+type TreeNode struct {
+	ID              uint64      `json:"id"`
+	Code            string      `json:"code"`
+	XMLID           string      `json:"xml_id"`
+	Name            string      `json:"name"`
+	IblockSectionID int64       `json:"iblock_section_id"`
+	Active          bool        `json:"active"`
+	Sort            uint64      `json:"sort"`
+	DepthLevel      uint64      `json:"depth_level"`
+	Children        []*TreeNode `json:"children"`
+}
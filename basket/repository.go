@@ -0,0 +1,380 @@
+package basket
+
+import (
+	"database/sql"
+	"errors"
+	"math"
+	"strconv"
+	"strings"
+
+	"github.com/jmoiron/sqlx"
+
+	"../catalog"
+	"../internal/database"
+	"../internal/iblock"
+)
+
+const productProvider = "\\Bitrix\\Catalog\\Product\\CatalogProvider"
+
+// Repository - хранилище корзин
+type Repository interface {
+	Items(fuserID uint32) ([]Basket, error)
+	Add(fuserID uint32, change Change) error
+	Update(fuserID uint32, change Change) error
+	Remove(fuserID uint32, productID int) error
+	Clear(fuserID uint32) error
+}
+
+type repository struct {
+	conn   *sqlx.DB
+	siteID string
+}
+
+// product - данные товара, нужные для записи в корзину
+type product struct {
+	Name        string               `db:"NAME"`
+	Code        database.NullString  `db:"CODE"`
+	XMLID       database.NullString  `db:"XML_ID"`
+	IblockID    uint64               `db:"IBLOCK_ID"`
+	SectionID   database.NullInt64   `db:"IBLOCK_SECTION_ID"`
+	Available   database.Bool        `db:"AVAILABLE"`
+	Quantity    float64              `db:"QUANTITY"`
+	CanBuyZero  database.Bool        `db:"CAN_BUY_ZERO"`
+	Weight      database.NullFloat64 `db:"WEIGHT"`
+	VatIncluded database.Bool        `db:"VAT_INCLUDED"`
+	VatRate     database.NullFloat64 `db:"VAT_RATE"`
+	PriceID     int                  `db:"PRICE_ID"`
+	PriceTypeID int                  `db:"CATALOG_GROUP_ID"`
+	Price       float64              `db:"PRICE"`
+	Currency    string               `db:"CURRENCY"`
+	Ratio       float64              `db:"RATIO"`
+}
+
+var fields = []string{
+	"b.BASE_PRICE",
+	"b.CAN_BUY",
+	"b.CURRENCY",
+	"b.CUSTOM_PRICE",
+	"b.DATE_INSERT",
+	"b.DELAY",
+	"b.DETAIL_PAGE_URL",
+	"b.DISCOUNT_PRICE",
+	"b.FUSER_ID",
+	"b.ID",
+	"b.NAME",
+	"b.NOTES",
+	"b.ORDER_ID",
+	"b.PRICE",
+	"b.PRICE_TYPE_ID",
+	"b.PRODUCT_ID",
+	"b.QUANTITY",
+	"b.RESERVED",
+	"b.RESERVE_QUANTITY",
+	"b.SORT",
+	"b.VAT_INCLUDED",
+	"b.VAT_RATE",
+	"b.WEIGHT",
+}
+
+// NewRepository - хранилище корзин поверх общего пула соединений
+func NewRepository(conn *sqlx.DB, siteID string) Repository {
+	return &repository{conn: conn, siteID: siteID}
+}
+
+func (repo *repository) Items(fuserID uint32) (basket []Basket, errorMessage error) {
+	sectionNameSelect := " (SELECT s.NAME FROM b_iblock_section s WHERE ID = " +
+		"(SELECT IBLOCK_SECTION_ID FROM b_iblock_element e WHERE e.ID = b.PRODUCT_ID)" +
+		")"
+	ratioSelect := ", " + catalog.SelectRatio("b.PRODUCT_ID")
+	selectStr := "select " +
+		strings.Join(fields, ", ") +
+		", " + sectionNameSelect +
+		"AS SECTION_NAME" +
+		ratioSelect + " AS MEASURE_RATIO" +
+		" from b_sale_basket b"
+	where := " where b.FUSER_ID = ?"
+
+	query := selectStr + where
+	errorMessage = repo.conn.Select(&basket, query, fuserID)
+
+	return
+}
+
+func (repo *repository) Add(fuserID uint32, change Change) error {
+	return repo.mutate(func(tx *sqlx.Tx) error {
+		return repo.addItem(tx, fuserID, change)
+	})
+}
+
+func (repo *repository) Update(fuserID uint32, change Change) error {
+	return repo.mutate(func(tx *sqlx.Tx) error {
+		return updateItem(tx, fuserID, change)
+	})
+}
+
+func (repo *repository) Remove(fuserID uint32, productID int) error {
+	return repo.mutate(func(tx *sqlx.Tx) error {
+		result, err := tx.Exec("DELETE FROM b_sale_basket"+
+			" WHERE FUSER_ID = ? AND PRODUCT_ID = ? AND ORDER_ID IS NULL", fuserID, productID)
+		if err != nil {
+			return err
+		}
+		affected, err := result.RowsAffected()
+		if err == nil && affected == 0 {
+			err = errors.New("product " + strconv.Itoa(productID) + " is not in basket")
+		}
+
+		return err
+	})
+}
+
+func (repo *repository) Clear(fuserID uint32) error {
+	return repo.mutate(func(tx *sqlx.Tx) error {
+		_, err := tx.Exec("DELETE FROM b_sale_basket WHERE FUSER_ID = ? AND ORDER_ID IS NULL", fuserID)
+		return err
+	})
+}
+
+// mutate - выполняем изменение корзины в транзакции
+func (repo *repository) mutate(change func(tx *sqlx.Tx) error) (errorMessage error) {
+	tx, err := repo.conn.Beginx()
+	if err != nil {
+		errorMessage = err
+		return
+	}
+
+	err = change(tx)
+	if err != nil {
+		tx.Rollback()
+		errorMessage = err
+		return
+	}
+
+	errorMessage = tx.Commit()
+
+	return
+}
+
+func (repo *repository) addItem(tx *sqlx.Tx, fuserID uint32, change Change) (errorMessage error) {
+	if change.ProductID <= 0 {
+		errorMessage = errors.New("product_id required")
+		return
+	}
+
+	if change.Quantity < 0 {
+		errorMessage = errors.New("quantity must not be negative")
+		return
+	}
+
+	item, err := getProduct(tx, change.ProductID)
+	if err != nil {
+		errorMessage = err
+		return
+	}
+	// без количества добавляем минимальную порцию - коэффициент единицы измерения
+	if change.Quantity == 0 {
+		change.Quantity = item.Ratio
+	}
+
+	var id int
+	var quantity float64
+	err = tx.QueryRowx("SELECT ID, QUANTITY FROM b_sale_basket"+
+		" WHERE FUSER_ID = ? AND PRODUCT_ID = ? AND ORDER_ID IS NULL FOR UPDATE",
+		fuserID, change.ProductID).Scan(&id, &quantity)
+	if err != nil && err != sql.ErrNoRows {
+		errorMessage = err
+		return
+	}
+
+	if err == nil {
+		change.Quantity += quantity
+		errorMessage = setQuantity(tx, id, change)
+		return
+	}
+
+	err = item.checkQuantity(change.ProductID, change.Quantity)
+	if err != nil {
+		errorMessage = err
+		return
+	}
+	err = item.loadPrice(tx, change.ProductID, change.Quantity)
+	if err != nil {
+		errorMessage = err
+		return
+	}
+
+	delay := database.NewBool(change.Delay != nil && *change.Delay)
+	vatIncluded := database.NewBool(!item.VatIncluded.Valid || item.VatIncluded.IsTrue())
+	urls, err := iblock.ElementURLs(tx, repo.siteID, []iblock.URLElement{{
+		ID:        uint64(change.ProductID),
+		IblockID:  item.IblockID,
+		SectionID: uint64(item.SectionID.Int64),
+		Code:      item.Code.String,
+		XMLID:     item.XMLID.String,
+	}})
+	if err != nil {
+		errorMessage = err
+		return
+	}
+
+	query := "INSERT INTO b_sale_basket (" +
+		"FUSER_ID, PRODUCT_ID, PRODUCT_PRICE_ID, PRICE_TYPE_ID, NAME, LID, MODULE, PRODUCT_PROVIDER_CLASS," +
+		" QUANTITY, PRICE, BASE_PRICE, DISCOUNT_PRICE, CUSTOM_PRICE, CURRENCY, WEIGHT," +
+		" VAT_RATE, VAT_INCLUDED, CAN_BUY, DELAY, RESERVED, DETAIL_PAGE_URL, NOTES, SORT," +
+		" DATE_INSERT, DATE_UPDATE" +
+		") VALUES (?, ?, ?, ?, ?, ?, 'catalog', ?, ?, ?, ?, 0, 'N', ?, ?, ?, ?, 'Y', ?, 'N', ?, '', 100, NOW(), NOW())"
+
+	_, errorMessage = tx.Exec(query,
+		fuserID, change.ProductID, item.PriceID, item.PriceTypeID, item.Name, repo.siteID, productProvider,
+		change.Quantity, item.Price, item.Price, item.Currency, item.Weight.Float64,
+		item.VatRate.Float64, vatIncluded.String, delay.String, urls[uint64(change.ProductID)].Page)
+
+	return
+}
+
+func updateItem(tx *sqlx.Tx, fuserID uint32, change Change) (errorMessage error) {
+	if change.Quantity < 0 {
+		errorMessage = errors.New("quantity must not be negative")
+		return
+	}
+
+	var id int
+	err := tx.QueryRowx("SELECT ID FROM b_sale_basket"+
+		" WHERE FUSER_ID = ? AND PRODUCT_ID = ? AND ORDER_ID IS NULL FOR UPDATE",
+		fuserID, change.ProductID).Scan(&id)
+	if err == sql.ErrNoRows {
+		errorMessage = errors.New("product " + strconv.Itoa(change.ProductID) + " is not in basket")
+		return
+	}
+	if err != nil {
+		errorMessage = err
+		return
+	}
+
+	// без количества меняем только отложенность, количество и цена остаются прежними
+	if change.Quantity == 0 {
+		if change.Delay == nil {
+			errorMessage = errors.New("quantity or delay required")
+			return
+		}
+		_, errorMessage = tx.Exec("UPDATE b_sale_basket SET DELAY = ?, DATE_UPDATE = NOW() WHERE ID = ?",
+			database.NewBool(*change.Delay).String, id)
+		return
+	}
+
+	errorMessage = setQuantity(tx, id, change)
+
+	return
+}
+
+// setQuantity - пишем новое количество (и отложенность) с актуальной ценой
+func setQuantity(tx *sqlx.Tx, basketID int, change Change) (errorMessage error) {
+	if change.Quantity <= 0 {
+		errorMessage = errors.New("quantity must be positive")
+		return
+	}
+
+	item, err := getProduct(tx, change.ProductID)
+	if err != nil {
+		errorMessage = err
+		return
+	}
+	err = item.checkQuantity(change.ProductID, change.Quantity)
+	if err != nil {
+		errorMessage = err
+		return
+	}
+	err = item.loadPrice(tx, change.ProductID, change.Quantity)
+	if err != nil {
+		errorMessage = err
+		return
+	}
+
+	set := "QUANTITY = ?, PRICE = ?, BASE_PRICE = ?, CURRENCY = ?, PRODUCT_PRICE_ID = ?, DATE_UPDATE = NOW()"
+	args := []interface{}{change.Quantity, item.Price, item.Price, item.Currency, item.PriceID}
+	if change.Delay != nil {
+		set += ", DELAY = ?"
+		args = append(args, database.NewBool(*change.Delay).String)
+	}
+	args = append(args, basketID)
+
+	_, errorMessage = tx.Exec("UPDATE b_sale_basket SET "+set+" WHERE ID = ?", args...)
+
+	return
+}
+
+// getProduct - остаток, вес, НДС и коэффициент товара, цена - отдельно в loadPrice по количеству
+func getProduct(tx *sqlx.Tx, productID int) (item product, errorMessage error) {
+	query := "SELECT e.NAME, e.CODE, e.XML_ID, e.IBLOCK_ID, e.IBLOCK_SECTION_ID, p.AVAILABLE, p.QUANTITY, p.CAN_BUY_ZERO, p.WEIGHT, p.VAT_INCLUDED," +
+		" (SELECT RATE FROM b_catalog_vat WHERE ID = p.VAT_ID) AS VAT_RATE," +
+		" " + catalog.SelectRatio("p.ID") + " AS RATIO" +
+		" FROM b_catalog_product p" +
+		" INNER JOIN b_iblock_element e ON e.ID = p.ID" +
+		" WHERE p.ID = ?"
+
+	err := tx.Get(&item, query, productID)
+	if err == sql.ErrNoRows {
+		errorMessage = errors.New("product " + strconv.Itoa(productID) + " not found")
+		return
+	}
+	if err != nil {
+		errorMessage = err
+		return
+	}
+	// в b_catalog_vat ставка в процентах (20), в b_sale_basket.VAT_RATE - долей (0.2)
+	item.VatRate.Float64 /= 100
+
+	return
+}
+
+// loadPrice - цена базового типа (b_catalog_group.BASE = Y), в диапазон QUANTITY_FROM/QUANTITY_TO которой
+// попадает количество, как цена выбирается в каталоге
+func (item *product) loadPrice(tx *sqlx.Tx, productID int, quantity float64) (errorMessage error) {
+	query := "SELECT pr.ID AS PRICE_ID, pr.CATALOG_GROUP_ID, pr.PRICE, pr.CURRENCY" +
+		" FROM b_catalog_price pr" +
+		" INNER JOIN b_catalog_group g ON g.ID = pr.CATALOG_GROUP_ID" +
+		" WHERE pr.PRODUCT_ID = ? AND g.BASE = 'Y'" +
+		" AND (pr.QUANTITY_FROM IS NULL OR pr.QUANTITY_FROM <= ?)" +
+		" AND (pr.QUANTITY_TO IS NULL OR pr.QUANTITY_TO >= ?)" +
+		" ORDER BY pr.QUANTITY_FROM DESC" +
+		" LIMIT 1"
+
+	err := tx.Get(item, query, productID, quantity, quantity)
+	if err == sql.ErrNoRows {
+		errorMessage = errors.New("product " + strconv.Itoa(productID) + " has no base price for quantity " +
+			strconv.FormatFloat(quantity, 'f', -1, 64))
+		return
+	}
+	errorMessage = err
+
+	return
+}
+
+// checkQuantity - проверяем, что товар можно купить в нужном количестве и оно кратно коэффициенту единицы измерения
+func (item *product) checkQuantity(productID int, quantity float64) (errorMessage error) {
+	if item.Available.String == "N" {
+		errorMessage = errors.New("product " + strconv.Itoa(productID) + " is not available")
+		return
+	}
+	if !multipleOf(quantity, item.Ratio) {
+		errorMessage = errors.New("quantity of product " + strconv.Itoa(productID) + " must be a multiple of " +
+			strconv.FormatFloat(item.Ratio, 'f', -1, 64))
+		return
+	}
+	if item.CanBuyZero.String != "Y" && quantity > item.Quantity {
+		errorMessage = errors.New("product " + strconv.Itoa(productID) + " has only " +
+			strconv.FormatFloat(item.Quantity, 'f', -1, 64) + " in stock")
+	}
+
+	return
+}
+
+// multipleOf - количество кратно коэффициенту (с точностью до хранения в базе, 4 знака)
+func multipleOf(quantity float64, ratio float64) bool {
+	if ratio <= 0 {
+		return true
+	}
+	steps := quantity / ratio
+
+	return steps >= 1-1e-6 && math.Abs(steps-math.Round(steps)) < 1e-6
+}
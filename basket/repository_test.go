@@ -0,0 +1,199 @@
+package basket
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/jmoiron/sqlx"
+)
+
+// testResult - строки, которые тестовая база отдает на запрос с подстрокой-ключом
+type testResult struct {
+	columns []string
+	rows    [][]driver.Value
+}
+
+// testExec - выполненный запрос на изменение с аргументами
+type testExec struct {
+	query string
+	args  []driver.Value
+}
+
+// testDatabase - драйвер database/sql без mysql: запросы на чтение получают заготовленные строки,
+// запросы на изменение записываются, opened - сколько соединений открыл пул
+type testDatabase struct {
+	results map[string]testResult
+	execs   []testExec
+	opened  int
+}
+
+func (database *testDatabase) Open(name string) (driver.Conn, error) {
+	return database.Connect(context.Background())
+}
+
+func (database *testDatabase) Connect(ctx context.Context) (driver.Conn, error) {
+	database.opened++
+	return &testConn{database: database}, nil
+}
+
+func (database *testDatabase) Driver() driver.Driver { return database }
+
+type testConn struct {
+	database *testDatabase
+}
+
+func (conn *testConn) Prepare(query string) (driver.Stmt, error) {
+	return &testStmt{database: conn.database, query: query}, nil
+}
+func (conn *testConn) Close() error              { return nil }
+func (conn *testConn) Begin() (driver.Tx, error) { return conn, nil }
+func (conn *testConn) Commit() error             { return nil }
+func (conn *testConn) Rollback() error           { return nil }
+
+type testStmt struct {
+	database *testDatabase
+	query    string
+}
+
+func (stmt *testStmt) Close() error  { return nil }
+func (stmt *testStmt) NumInput() int { return -1 }
+
+func (stmt *testStmt) Exec(args []driver.Value) (driver.Result, error) {
+	stmt.database.execs = append(stmt.database.execs, testExec{query: stmt.query, args: args})
+	return driver.RowsAffected(1), nil
+}
+
+func (stmt *testStmt) Query(args []driver.Value) (driver.Rows, error) {
+	for key, result := range stmt.database.results {
+		if strings.Contains(stmt.query, key) {
+			return &testRows{result: result}, nil
+		}
+	}
+
+	return nil, errors.New("unexpected query " + stmt.query)
+}
+
+type testRows struct {
+	result testResult
+	next   int
+}
+
+func (rows *testRows) Columns() []string { return rows.result.columns }
+func (rows *testRows) Close() error      { return nil }
+
+func (rows *testRows) Next(dest []driver.Value) error {
+	if rows.next >= len(rows.result.rows) {
+		return io.EOF
+	}
+	copy(dest, rows.result.rows[rows.next])
+	rows.next++
+
+	return nil
+}
+
+// newTestRepository - корзина поверх тестовой базы с товаром 10: ставка НДС 20% в b_catalog_vat,
+// коэффициент 1, базовая цена 150 RUB, в корзине товара еще нет
+func newTestRepository() (*repository, *testDatabase) {
+	database := &testDatabase{results: map[string]testResult{
+		"FROM b_catalog_product p": {
+			columns: []string{"NAME", "CODE", "XML_ID", "IBLOCK_ID", "IBLOCK_SECTION_ID", "AVAILABLE", "QUANTITY",
+				"CAN_BUY_ZERO", "WEIGHT", "VAT_INCLUDED", "VAT_RATE", "RATIO"},
+			rows: [][]driver.Value{{"Шкаф", "shkaf", nil, int64(2), nil, "Y", float64(10),
+				"N", float64(5000), "N", float64(20), float64(1)}},
+		},
+		"FROM b_sale_basket": {columns: []string{"ID", "QUANTITY"}},
+		"FROM b_catalog_price pr": {
+			columns: []string{"PRICE_ID", "CATALOG_GROUP_ID", "PRICE", "CURRENCY"},
+			rows:    [][]driver.Value{{int64(7), int64(1), float64(150), "RUB"}},
+		},
+		"FROM b_iblock b": {
+			columns: []string{"ID", "CODE", "IBLOCK_TYPE_ID", "XML_ID", "DETAIL_PAGE_URL", "SECTION_PAGE_URL",
+				"LIST_PAGE_URL", "SITE_DIR", "SERVER_NAME"},
+			rows: [][]driver.Value{{int64(2), "catalog", "catalog", nil, "#SITE_DIR#/catalog/#ELEMENT_CODE#/", nil,
+				nil, "/", nil}},
+		},
+	}}
+
+	conn := sqlx.NewDb(sql.OpenDB(database), "mysql")
+	conn.SetMaxOpenConns(2)
+
+	return &repository{conn: conn, siteID: "s1"}, database
+}
+
+func TestAddItem(t *testing.T) {
+	repo, database := newTestRepository()
+
+	quantity := 2.0
+	err := repo.Add(5, Change{ProductID: 10, Quantity: quantity})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(database.execs) != 1 || !strings.HasPrefix(database.execs[0].query, "INSERT INTO b_sale_basket") {
+		t.Fatalf("execs = %v, want one insert", database.execs)
+	}
+
+	args := database.execs[0].args
+	if args[7] != quantity || args[8] != float64(150) || args[10] != "RUB" {
+		t.Errorf("insert quantity %v price %v currency %v", args[7], args[8], args[10])
+	}
+	if args[12] != 0.2 {
+		t.Errorf("insert VAT_RATE = %v, want 0.2 from catalog rate 20", args[12])
+	}
+	if args[13] != "N" {
+		t.Errorf("insert VAT_INCLUDED = %v, want N", args[13])
+	}
+	if args[15] != "/catalog/shkaf/" {
+		t.Errorf("insert DETAIL_PAGE_URL = %v", args[15])
+	}
+	if database.opened != 1 {
+		t.Errorf("add opened %d connections, want everything inside one transaction", database.opened)
+	}
+}
+
+func TestAddItemDefaultQuantity(t *testing.T) {
+	repo, database := newTestRepository()
+
+	err := repo.Add(5, Change{ProductID: 10})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(database.execs) != 1 || database.execs[0].args[7] != float64(1) {
+		t.Errorf("add without quantity execs = %v, want quantity 1 (ratio)", database.execs)
+	}
+}
+
+func TestChangeNegativeQuantity(t *testing.T) {
+	repo, database := newTestRepository()
+
+	if err := repo.Add(5, Change{ProductID: 10, Quantity: -1}); err == nil {
+		t.Error("Add with negative quantity want error")
+	}
+	if err := repo.Update(5, Change{ProductID: 10, Quantity: -1}); err == nil {
+		t.Error("Update with negative quantity want error")
+	}
+	if len(database.execs) != 0 {
+		t.Errorf("negative quantity changed basket: %v", database.execs)
+	}
+}
+
+func TestUpdateDelayOnly(t *testing.T) {
+	repo, database := newTestRepository()
+	database.results["FROM b_sale_basket"] = testResult{columns: []string{"ID"}, rows: [][]driver.Value{{int64(3)}}}
+
+	delay := true
+	err := repo.Update(5, Change{ProductID: 10, Delay: &delay})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(database.execs) != 1 || strings.Contains(database.execs[0].query, "QUANTITY") {
+		t.Fatalf("delay-only update execs = %v, want only DELAY", database.execs)
+	}
+	if database.execs[0].args[0] != "Y" || database.execs[0].args[1] != int64(3) {
+		t.Errorf("delay-only update args = %v", database.execs[0].args)
+	}
+}
@@ -0,0 +1,118 @@
+package basket
+
+import (
+	"encoding/json"
+	"math"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+
+	"../api"
+)
+
+// Summary - итоги корзины в одной валюте, тип общий с client
+type Summary = api.Summary
+
+// VatAmount - сумма НДС по одной ставке
+type VatAmount = api.VatAmount
+
+// Summarize - получаем итоги корзины по валютам
+func (handler *Handler) Summarize(response http.ResponseWriter, request *http.Request) {
+	requestURL := strings.Split(request.RequestURI, "/")
+	fuserID, _ := strconv.Atoi(requestURL[2])
+
+	basket, err := handler.repository.Items(uint32(fuserID))
+	if err != nil {
+		response.WriteHeader(http.StatusBadRequest)
+		response.Write([]byte(err.Error()))
+		return
+	}
+
+	result, _ := json.Marshal(summarize(basket))
+	response.Header().Set("Content-Type", "application/json")
+	response.WriteHeader(http.StatusOK)
+	response.Write(result)
+}
+
+// purchasable - позиция участвует в итогах: можно купить, не отложена и еще не в заказе
+func (item *Basket) purchasable() bool {
+	return item.CanBuy.IsTrue() && !item.Delay.IsTrue() && !item.OrderID.Valid
+}
+
+func summarize(basket []Basket) (summaries []*Summary) {
+	byCurrency := make(map[string]*Summary)
+	vatByCurrency := make(map[string]map[string]*VatAmount)
+
+	for index := range basket {
+		item := &basket[index]
+		if !item.purchasable() {
+			continue
+		}
+
+		summary, found := byCurrency[item.Currency]
+		if !found {
+			summary = &Summary{Currency: item.Currency, InvalidQuantity: []int{}}
+			byCurrency[item.Currency] = summary
+			vatByCurrency[item.Currency] = make(map[string]*VatAmount)
+			summaries = append(summaries, summary)
+		}
+
+		quantity := item.Quantity
+		lineTotal := item.Price * quantity
+		summary.Subtotal += lineTotal
+		summary.BaseTotal += item.BasePrice * quantity
+		summary.DiscountTotal += item.Discount * quantity
+		summary.Weight += item.Weight * quantity / 1000
+		summary.Quantity += item.Quantity
+		summary.Count++
+		if !multipleOf(item.Quantity, item.MeasureRatio) {
+			summary.InvalidQuantity = append(summary.InvalidQuantity, item.ProductID)
+		}
+
+		if item.VatRate == 0 {
+			continue
+		}
+		included := item.VatIncluded.IsTrue()
+		key := strconv.FormatFloat(item.VatRate, 'f', -1, 64) + strconv.FormatBool(included)
+		vat, found := vatByCurrency[item.Currency][key]
+		if !found {
+			vat = &VatAmount{Rate: item.VatRate, Included: included}
+			vatByCurrency[item.Currency][key] = vat
+			summary.Vat = append(summary.Vat, vat)
+		}
+		vat.Base += lineTotal
+		if included {
+			vat.Amount += lineTotal * item.VatRate / (1 + item.VatRate)
+		} else {
+			vat.Amount += lineTotal * item.VatRate
+		}
+	}
+
+	for _, summary := range summaries {
+		summary.Total = summary.Subtotal
+		for _, vat := range summary.Vat {
+			vat.Base = round(vat.Base)
+			vat.Amount = round(vat.Amount)
+			summary.VatTotal += vat.Amount
+			if !vat.Included {
+				summary.Total += vat.Amount
+			}
+		}
+		sort.Slice(summary.Vat, func(i, j int) bool { return summary.Vat[i].Rate < summary.Vat[j].Rate })
+
+		summary.Subtotal = round(summary.Subtotal)
+		summary.BaseTotal = round(summary.BaseTotal)
+		summary.DiscountTotal = round(summary.DiscountTotal)
+		summary.VatTotal = round(summary.VatTotal)
+		summary.Total = round(summary.Total)
+		summary.Weight = round(summary.Weight)
+	}
+	sort.Slice(summaries, func(i, j int) bool { return summaries[i].Currency < summaries[j].Currency })
+
+	return
+}
+
+func round(value float64) float64 {
+	return math.Round(value*100) / 100
+}
@@ -0,0 +1,177 @@
+package basket
+
+import (
+	"database/sql"
+	"reflect"
+	"testing"
+
+	"../internal/database"
+)
+
+// item - позиция корзины, которую можно купить
+func item(productID int, currency string, price, quantity float64) Basket {
+	return Basket{
+		ProductID:    productID,
+		Currency:     currency,
+		Price:        price,
+		BasePrice:    price,
+		Quantity:     quantity,
+		MeasureRatio: 1,
+		CanBuy:       database.NewBool(true),
+		Delay:        database.NewBool(false),
+		VatIncluded:  database.NewBool(true),
+	}
+}
+
+func withVat(basket Basket, rate float64, included bool) Basket {
+	basket.VatRate = rate
+	basket.VatIncluded = database.NewBool(included)
+	return basket
+}
+
+func TestSummarize(t *testing.T) {
+	discounted := item(4, "RUB", 90, 1)
+	discounted.BasePrice = 100
+	discounted.Discount = 10
+
+	heavy := item(5, "USD", 9.99, 3)
+	heavy.Weight = 500
+
+	delayed := item(6, "RUB", 1000, 1)
+	delayed.Delay = database.NewBool(true)
+	unavailable := item(7, "RUB", 1000, 1)
+	unavailable.CanBuy = database.NewBool(false)
+	ordered := item(8, "EUR", 1000, 1)
+	ordered.OrderID = database.NullInt64{NullInt64: sql.NullInt64{Int64: 15, Valid: true}}
+
+	ratio := item(9, "USD", 2, 1.2)
+	ratio.MeasureRatio = 0.5
+
+	tests := []struct {
+		name   string
+		basket []Basket
+		want   []*Summary
+	}{
+		{
+			name:   "empty",
+			basket: []Basket{},
+			want:   nil,
+		},
+		{
+			name:   "vat included",
+			basket: []Basket{withVat(item(1, "RUB", 100, 2), 0.2, true)},
+			want: []*Summary{{
+				Currency: "RUB", Subtotal: 200, BaseTotal: 200, VatTotal: 33.33, Total: 200,
+				Vat:      []*VatAmount{{Rate: 0.2, Included: true, Base: 200, Amount: 33.33}},
+				Quantity: 2, Count: 1, InvalidQuantity: []int{},
+			}},
+		},
+		{
+			name:   "vat excluded",
+			basket: []Basket{withVat(item(1, "RUB", 100, 2), 0.2, false)},
+			want: []*Summary{{
+				Currency: "RUB", Subtotal: 200, BaseTotal: 200, VatTotal: 40, Total: 240,
+				Vat:      []*VatAmount{{Rate: 0.2, Included: false, Base: 200, Amount: 40}},
+				Quantity: 2, Count: 1, InvalidQuantity: []int{},
+			}},
+		},
+		{
+			name: "mixed vat rates",
+			basket: []Basket{
+				withVat(item(1, "RUB", 100, 2), 0.2, true),
+				withVat(item(2, "RUB", 50, 3), 0.1, false),
+				withVat(item(3, "RUB", 10, 1), 0.2, true),
+				item(4, "RUB", 5, 1),
+			},
+			want: []*Summary{{
+				Currency: "RUB", Subtotal: 365, BaseTotal: 365, VatTotal: 50, Total: 380,
+				Vat: []*VatAmount{
+					{Rate: 0.1, Included: false, Base: 150, Amount: 15},
+					{Rate: 0.2, Included: true, Base: 210, Amount: 35},
+				},
+				Quantity: 7, Count: 4, InvalidQuantity: []int{},
+			}},
+		},
+		{
+			name:   "per currency, skipped items",
+			basket: []Basket{heavy, discounted, delayed, unavailable, ordered, ratio},
+			want: []*Summary{
+				{
+					Currency: "RUB", Subtotal: 90, BaseTotal: 100, DiscountTotal: 10, Total: 90,
+					Quantity: 1, Count: 1, InvalidQuantity: []int{},
+				},
+				{
+					Currency: "USD", Subtotal: 32.37, BaseTotal: 32.37, Total: 32.37, Weight: 1.5,
+					Quantity: 4.2, Count: 2, InvalidQuantity: []int{9},
+				},
+			},
+		},
+		{
+			name: "fractional quantity",
+			basket: []Basket{func() Basket {
+				fractional := item(10, "RUB", 33.33, 0.1+0.1+0.1)
+				fractional.MeasureRatio = 0.1
+				return fractional
+			}()},
+			want: []*Summary{{
+				Currency: "RUB", Subtotal: 10, BaseTotal: 10, Total: 10,
+				Quantity: 0.1 + 0.1 + 0.1, Count: 1, InvalidQuantity: []int{},
+			}},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			summaries := summarize(test.basket)
+			if len(summaries) != len(test.want) {
+				t.Fatalf("summarize returned %d currencies, want %d", len(summaries), len(test.want))
+			}
+			for index, summary := range summaries {
+				if !reflect.DeepEqual(summary, test.want[index]) {
+					t.Errorf("summary %s:\n got %+v %+v\nwant %+v %+v", summary.Currency,
+						*summary, vats(summary.Vat), *test.want[index], vats(test.want[index].Vat))
+				}
+			}
+		})
+	}
+}
+
+func vats(list []*VatAmount) (values []VatAmount) {
+	for _, vat := range list {
+		values = append(values, *vat)
+	}
+
+	return
+}
+
+func TestMultipleOf(t *testing.T) {
+	tests := []struct {
+		quantity float64
+		ratio    float64
+		want     bool
+	}{
+		{3, 1, true},
+		{2.5, 1, false},
+		{1.5, 0.5, true},
+		{1.2, 0.5, false},
+		{0.3, 0.1, true},
+		{0.1 + 0.1 + 0.1, 0.1, true},
+		{0.1 * 3, 0.1, true},
+		{0.7, 0.1, true},
+		{0.35, 0.1, false},
+		{0.05, 0.1, false},
+		{0, 1, false},
+		{1.0001, 1, false},
+		{2.4999, 0.5, false},
+		{0.75, 0.25, true},
+		{12.5, 2.5, true},
+		{5, 0, true},
+		{5, -1, true},
+	}
+
+	for _, test := range tests {
+		if got := multipleOf(test.quantity, test.ratio); got != test.want {
+			t.Errorf("multipleOf(%v, %v) = %v, want %v", test.quantity, test.ratio, got, test.want)
+		}
+	}
+}
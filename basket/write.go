@@ -0,0 +1,97 @@
+package basket
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"../api"
+)
+
+// Change - тело запроса на добавление или изменение позиции корзины, тип общий с client
+type Change = api.Change
+
+// Add - добавляем товар в корзину, если он уже есть - увеличиваем количество
+func (handler *Handler) Add(response http.ResponseWriter, request *http.Request) {
+	requestURL := strings.Split(request.RequestURI, "/")
+	fuserID, _ := strconv.Atoi(requestURL[2])
+
+	change, err := readChange(request)
+	if err != nil {
+		response.WriteHeader(http.StatusBadRequest)
+		response.Write([]byte(err.Error()))
+		return
+	}
+
+	err = handler.repository.Add(uint32(fuserID), change)
+	handler.writeBasket(response, uint32(fuserID), err)
+}
+
+// Update - меняем количество или отложенность товара в корзине
+func (handler *Handler) Update(response http.ResponseWriter, request *http.Request) {
+	requestURL := strings.Split(request.RequestURI, "/")
+	fuserID, _ := strconv.Atoi(requestURL[2])
+	productID, _ := strconv.Atoi(requestURL[4])
+
+	change, err := readChange(request)
+	if err != nil {
+		response.WriteHeader(http.StatusBadRequest)
+		response.Write([]byte(err.Error()))
+		return
+	}
+	change.ProductID = productID
+
+	err = handler.repository.Update(uint32(fuserID), change)
+	handler.writeBasket(response, uint32(fuserID), err)
+}
+
+// Remove - удаляем товар из корзины
+func (handler *Handler) Remove(response http.ResponseWriter, request *http.Request) {
+	requestURL := strings.Split(request.RequestURI, "/")
+	fuserID, _ := strconv.Atoi(requestURL[2])
+	productID, _ := strconv.Atoi(requestURL[4])
+
+	err := handler.repository.Remove(uint32(fuserID), productID)
+	handler.writeBasket(response, uint32(fuserID), err)
+}
+
+// Clear - очищаем корзину пользователя
+func (handler *Handler) Clear(response http.ResponseWriter, request *http.Request) {
+	requestURL := strings.Split(request.RequestURI, "/")
+	fuserID, _ := strconv.Atoi(requestURL[2])
+
+	err := handler.repository.Clear(uint32(fuserID))
+	handler.writeBasket(response, uint32(fuserID), err)
+}
+
+func readChange(request *http.Request) (change Change, errorMessage error) {
+	body, _ := ioutil.ReadAll(request.Body)
+	defer request.Body.Close()
+
+	errorMessage = json.Unmarshal(body, &change)
+
+	return
+}
+
+// writeBasket - отдаем корзину после изменения или ошибку
+func (handler *Handler) writeBasket(response http.ResponseWriter, fuserID uint32, err error) {
+	if err != nil {
+		response.WriteHeader(http.StatusBadRequest)
+		response.Write([]byte(err.Error()))
+		return
+	}
+
+	basket, err := handler.repository.Items(fuserID)
+	if err != nil {
+		response.WriteHeader(http.StatusBadRequest)
+		response.Write([]byte(err.Error()))
+		return
+	}
+
+	result, _ := json.Marshal(basket)
+	response.Header().Set("Content-Type", "application/json")
+	response.WriteHeader(http.StatusOK)
+	response.Write(result)
+}
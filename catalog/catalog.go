@@ -1,118 +1,117 @@
 package catalog
 
 import (
-	"database/sql"
 	"encoding/json"
-	"io/ioutil"
-	"log"
+	"errors"
 	"net/http"
 	"strconv"
 	"strings"
 
-	_ "github.com/go-sql-driver/mysql"
-	"github.com/jmoiron/sqlx"
-	yaml "gopkg.in/yaml.v2"
-)
-
-const (
-	tableWithOffersProp = "b_iblock_element_prop_s4" // Таблица с соответствиями id торговых предложений и id товара
-	cml2Link            = 3451                       // Ссылка на родительский объект для торговых предложений
+	"../api"
+	"../internal/database"
 )
 
 // Catalog структура данных для каталога
 type Catalog struct {
-	ID               uint32      `db:"ID" json:"id"`
-	Avaliable        bitrixBool  `db:"AVAILABLE" json:"available"`
-	Quantity         int64       `db:"QUANTITY" json:"quantity"`
-	QuantityReserved nullInt64   `db:"QUANTITY_RESERVED" json:"quantity_reserved"`
-	Weight           nullFloat64 `db:"WEIGHT" json:"weight"`
-	Width            nullFloat64 `db:"WIDTH" json:"width"`
-	Length           nullFloat64 `db:"LENGTH" json:"length"`
-	Height           nullFloat64 `db:"HEIGHT" json:"height"`
-	Measure          nullInt64   `db:"MEASURE" json:"measure"`
-	Type             string      `db:"TYPE" json:"type"`
-	VatIncluded      bitrixBool  `db:"VAT_INCLUDED" json:"vat_included"`
-	PriceType        string      `db:"PRICE_TYPE" json:"price_type"`
-	WithoutOrder     bitrixBool  `db:"WITHOUT_ORDER" json:"without_order"`
-	SelectBestPrice  bitrixBool  `db:"SELECT_BEST_PRICE" json:"select_best_price"`
-	Price            nullFloat64 `db:"PRICE" json:"price"`
-	Vat              nullFloat64 `db:"VAT_RATE" json:"vat"`
-	Offers           []uint32    `json:"offers"`
-}
-
-type nullInt64 struct {
-	sql.NullInt64
-}
-
-type nullFloat64 struct {
-	sql.NullFloat64
-}
-
-type nullString struct {
-	sql.NullString
-}
-
-type bitrixBool struct {
-	sql.NullString
-}
-
-// EnvStruct - структура для данных из env.yml файла
-type EnvStruct struct {
-	DBName     string `yaml:"db_name"`
-	DBLogin    string `yaml:"db_login"`
-	DBPassword string `yaml:"db_password"`
-	DBHost     string `yaml:"db_host"`
-	DBPort     int    `yaml:"db_port"`
-}
-
-var env EnvStruct
-var fields []string
-var mysqlConnectString string
-
-func init() {
-	fields = []string{
-		"p.ID",
-		"p.AVAILABLE",
-		"p.QUANTITY",
-		"p.QUANTITY_RESERVED",
-		"p.WEIGHT",
-		"p.WIDTH",
-		"p.LENGTH",
-		"p.HEIGHT",
-		"p.MEASURE",
-		"p.TYPE",
-		"p.VAT_INCLUDED",
-		"p.PRICE_TYPE",
-		"p.WITHOUT_ORDER",
-		"p.SELECT_BEST_PRICE",
-	}
-
-	fileEnv, err := ioutil.ReadFile("env.yml")
-	if err != nil {
-		log.Fatal(err)
-	}
+	ID               uint32               `db:"ID" json:"id"`
+	Avaliable        database.Bool        `db:"AVAILABLE" json:"available"`
+	Quantity         int64                `db:"QUANTITY" json:"quantity"`
+	QuantityReserved database.NullInt64   `db:"QUANTITY_RESERVED" json:"quantity_reserved"`
+	Weight           database.NullFloat64 `db:"WEIGHT" json:"weight"`
+	Width            database.NullFloat64 `db:"WIDTH" json:"width"`
+	Length           database.NullFloat64 `db:"LENGTH" json:"length"`
+	Height           database.NullFloat64 `db:"HEIGHT" json:"height"`
+	MeasureID        database.NullInt64   `db:"MEASURE" json:"measure_id"`
+	Measure          Measure              `db:"measure" json:"measure"`
+	Ratio            float64              `db:"RATIO" json:"ratio"`
+	Type             string               `db:"TYPE" json:"type"`
+	VatIncluded      database.Bool        `db:"VAT_INCLUDED" json:"vat_included"`
+	PriceType        string               `db:"PRICE_TYPE" json:"price_type"`
+	WithoutOrder     database.Bool        `db:"WITHOUT_ORDER" json:"without_order"`
+	SelectBestPrice  database.Bool        `db:"SELECT_BEST_PRICE" json:"select_best_price"`
+	Price            database.NullFloat64 `json:"price"`
+	Currency         string               `json:"currency"`
+	Vat              database.NullFloat64 `db:"VAT_RATE" json:"vat"`
+	Prices           map[string][]Price   `json:"prices"`
+	Offers           []uint32             `json:"offers"`
+	Stores           []StoreAmount        `json:"stores,omitempty"`
+}
 
-	err = yaml.Unmarshal(fileEnv, &env)
-	if err != nil {
-		log.Fatal(err)
-	}
+// ProductsRequest - тело запроса по нескольким товарам, тип общий с client
+type ProductsRequest = api.ProductsRequest
+
+// maxProducts - сколько товаров можно запросить за раз
+const maxProducts = 1000
 
-	mysqlConnectString = env.DBLogin + ":" + env.DBPassword +
-		"@tcp(" + env.DBHost + ":" + strconv.Itoa(env.DBPort) + ")/" + env.DBName
+// BulkInfo - ответ по нескольким товарам: найденные по ID и ID, которых нет в каталоге
+type BulkInfo struct {
+	Items    map[uint32]*Catalog `json:"items"`
+	NotFound []uint32            `json:"not_found"`
 }
 
-// Info - достаем информацию по продукту
-func Info(response http.ResponseWriter, request *http.Request) {
+// Measure - единица измерения товара из b_catalog_measure, без своей - единица по умолчанию
+type Measure struct {
+	ID         database.NullInt64  `db:"ID" json:"id"`
+	Code       database.NullInt64  `db:"CODE" json:"code"`
+	Title      database.NullString `db:"MEASURE_TITLE" json:"title"`
+	Symbol     database.NullString `db:"SYMBOL" json:"symbol"`
+	SymbolIntl database.NullString `db:"SYMBOL_INTL" json:"symbol_intl"`
+}
+
+// Price - цена товара по типу цены
+type Price struct {
+	ID             uint32             `db:"ID" json:"id"`
+	ProductID      uint32             `db:"PRODUCT_ID" json:"product_id"`
+	CatalogGroupID uint32             `db:"CATALOG_GROUP_ID" json:"catalog_group_id"`
+	Price          float64            `db:"PRICE" json:"price"`
+	Currency       string             `db:"CURRENCY" json:"currency"`
+	QuantityFrom   database.NullInt64 `db:"QUANTITY_FROM" json:"quantity_from"`
+	QuantityTo     database.NullInt64 `db:"QUANTITY_TO" json:"quantity_to"`
+	Code           string             `db:"CODE" json:"code"`
+	Base           database.Bool      `db:"BASE" json:"base"`
+	CanBuy         bool               `json:"can_buy"`
+}
+
+// Handler - обработчики запросов к каталогу
+type Handler struct {
+	repository Repository
+}
+
+// NewHandler - создаем обработчики с переданным хранилищем
+func NewHandler(repository Repository) *Handler {
+	return &Handler{repository: repository}
+}
+
+// Info - достаем информацию по продукту, ?user_groups=2,5 - только цены, видимые этим группам пользователей,
+// ?expand=stores - с остатками по складам
+func (handler *Handler) Info(response http.ResponseWriter, request *http.Request) {
 	requestURL := strings.Split(request.RequestURI, "/")
 	productID, _ := strconv.Atoi(requestURL[2])
 
-	catalog, err := getData(uint32(productID))
+	groups, err := userGroups(request)
+	if err != nil {
+		response.WriteHeader(http.StatusBadRequest)
+		response.Write([]byte(err.Error()))
+		return
+	}
+
+	catalog, err := handler.repository.Product(uint32(productID), groups)
 	if err != nil {
 		response.WriteHeader(http.StatusBadRequest)
 		response.Write([]byte(err.Error()))
 		return
 	}
 
+	if request.URL.Query().Get("expand") == "stores" {
+		stores, err := handler.repository.Stores([]uint32{catalog.ID})
+		if err != nil {
+			response.WriteHeader(http.StatusBadRequest)
+			response.Write([]byte(err.Error()))
+			return
+		}
+		catalog.Stores = stores[catalog.ID]
+	}
+
 	result, _ := json.Marshal(catalog)
 
 	response.Header().Set("Content-Type", "application/json")
@@ -120,120 +119,227 @@ func Info(response http.ResponseWriter, request *http.Request) {
 	response.Write([]byte(result))
 }
 
-// HaveOffers - проверяем есть ли у продукта торговые предложения
-func HaveOffers(response http.ResponseWriter, request *http.Request) {
-	requestURL := strings.Split(request.RequestURI, "/")
-	productID, _ := strconv.Atoi(requestURL[2])
+// BulkInfo - информация по нескольким продуктам одним набором запросов, ?user_groups= и ?expand=stores как в Info.
+// Товары без записи в каталоге попадают в not_found, остальные отдаются
+func (handler *Handler) BulkInfo(response http.ResponseWriter, request *http.Request) {
+	productIDs, err := readProductIDs(request)
+	if err != nil {
+		response.WriteHeader(http.StatusBadRequest)
+		response.Write([]byte(err.Error()))
+		return
+	}
+
+	groups, err := userGroups(request)
+	if err != nil {
+		response.WriteHeader(http.StatusBadRequest)
+		response.Write([]byte(err.Error()))
+		return
+	}
 
-	catalog, err := getData(uint32(productID))
+	products, err := handler.repository.Products(productIDs, groups)
 	if err != nil {
 		response.WriteHeader(http.StatusBadRequest)
 		response.Write([]byte(err.Error()))
 		return
 	}
 
+	if request.URL.Query().Get("expand") == "stores" && len(products) > 0 {
+		found := make([]uint32, 0, len(products))
+		for productID := range products {
+			found = append(found, productID)
+		}
+		stores, err := handler.repository.Stores(found)
+		if err != nil {
+			response.WriteHeader(http.StatusBadRequest)
+			response.Write([]byte(err.Error()))
+			return
+		}
+		for productID, catalog := range products {
+			catalog.Stores = stores[productID]
+		}
+	}
+
+	result, _ := json.Marshal(newBulkInfo(productIDs, products))
+
+	response.Header().Set("Content-Type", "application/json")
 	response.WriteHeader(http.StatusOK)
-	if catalog.haveOffers() {
-		response.Write([]byte("true"))
-	} else {
-		response.Write([]byte("false"))
+	response.Write(result)
+}
+
+// Offers - торговые предложения продукта с матрицей выбора, ?tree=SIZE,COLOR - свойства дерева предложений
+func (handler *Handler) Offers(response http.ResponseWriter, request *http.Request) {
+	requestURL := strings.Split(request.RequestURI, "/")
+	productID, _ := strconv.Atoi(requestURL[2])
+
+	var tree []string
+	if value := request.URL.Query().Get("tree"); value != "" {
+		tree = strings.Split(value, ",")
+	}
+
+	offers, err := handler.repository.Offers(uint32(productID), tree)
+	if err != nil {
+		response.WriteHeader(http.StatusBadRequest)
+		response.Write([]byte(err.Error()))
+		return
 	}
 
+	result, _ := json.Marshal(offers)
+
+	response.Header().Set("Content-Type", "application/json")
+	response.WriteHeader(http.StatusOK)
+	response.Write(result)
 }
 
-func getData(productID uint32) (catalog Catalog, errorMessage error) {
-	conn, err := sqlx.Connect("mysql", mysqlConnectString)
-	defer conn.Close()
+// Stores - остатки продукта по активным складам, товар без записи в каталоге - 400
+func (handler *Handler) Stores(response http.ResponseWriter, request *http.Request) {
+	requestURL := strings.Split(request.RequestURI, "/")
+	productID, _ := strconv.Atoi(requestURL[2])
 
+	stores, err := handler.repository.Stores([]uint32{uint32(productID)})
 	if err != nil {
-		errorMessage = err
+		response.WriteHeader(http.StatusBadRequest)
+		response.Write([]byte(err.Error()))
+		return
 	}
-	selectPrice := ", (SELECT PRICE FROM b_catalog_price WHERE PRODUCT_ID = p.ID) AS PRICE"
-	selectVat := ", (SELECT RATE FROM b_catalog_vat WHERE ID = p.VAT_ID) AS VAT_RATE"
-	selectStr := "SELECT " +
-		strings.Join(fields, ", ") +
-		selectPrice +
-		selectVat +
-		" FROM b_catalog_product p"
-	where := " WHERE p.ID = ?"
+	productStores, found := stores[uint32(productID)]
+	if !found {
+		response.WriteHeader(http.StatusBadRequest)
+		response.Write([]byte("product " + strconv.Itoa(productID) + " not found"))
+		return
+	}
+
+	result, _ := json.Marshal(productStores)
 
-	query := selectStr + where
+	response.Header().Set("Content-Type", "application/json")
+	response.WriteHeader(http.StatusOK)
+	response.Write(result)
+}
 
-	err = conn.Get(&catalog, query, productID)
+// BulkStores - остатки по складам для нескольких продуктов, ответ - по ID продукта,
+// товаров без записи в каталоге в ответе нет
+func (handler *Handler) BulkStores(response http.ResponseWriter, request *http.Request) {
+	productIDs, err := readProductIDs(request)
 	if err != nil {
-		errorMessage = err
+		response.WriteHeader(http.StatusBadRequest)
+		response.Write([]byte(err.Error()))
 		return
 	}
 
-	offers, err := getOffers(productID, conn)
+	stores, err := handler.repository.Stores(productIDs)
 	if err != nil {
-		errorMessage = err
+		response.WriteHeader(http.StatusBadRequest)
+		response.Write([]byte(err.Error()))
 		return
 	}
 
-	catalog.Offers = offers
+	result, _ := json.Marshal(stores)
 
-	return
+	response.Header().Set("Content-Type", "application/json")
+	response.WriteHeader(http.StatusOK)
+	response.Write(result)
 }
 
-func getOffers(productID uint32, conn *sqlx.DB) (offers []uint32, errorMessage error) {
-	query := "SELECT IBLOCK_ELEMENT_ID" +
-		" FROM " + tableWithOffersProp +
-		" WHERE PROPERTY_" + strconv.Itoa(cml2Link) + " = ?"
-
-	err := conn.Select(&offers, query, productID)
+// HaveOffers - проверяем есть ли у продукта торговые предложения
+func (handler *Handler) HaveOffers(response http.ResponseWriter, request *http.Request) {
+	requestURL := strings.Split(request.RequestURI, "/")
+	productID, _ := strconv.Atoi(requestURL[2])
 
+	catalog, err := handler.repository.Product(uint32(productID), nil)
 	if err != nil {
-		errorMessage = err
+		response.WriteHeader(http.StatusBadRequest)
+		response.Write([]byte(err.Error()))
+		return
+	}
+
+	response.WriteHeader(http.StatusOK)
+	if catalog.haveOffers() {
+		response.Write([]byte("true"))
+	} else {
+		response.Write([]byte("false"))
 	}
 
-	return
 }
 
-func (catalog *Catalog) haveOffers() (have bool) {
-	if len(catalog.Offers) > 0 {
-		have = true
+// readProductIDs - ID товаров из тела запроса, не больше maxProducts
+func readProductIDs(request *http.Request) (productIDs []uint32, errorMessage error) {
+	var body ProductsRequest
+
+	defer request.Body.Close()
+	errorMessage = json.NewDecoder(request.Body).Decode(&body)
+	if errorMessage != nil {
+		return
+	}
+	if len(body.ProductIDs) == 0 || len(body.ProductIDs) > maxProducts {
+		errorMessage = errors.New("product_ids must contain from 1 to " + strconv.Itoa(maxProducts) + " ids")
+		return
 	}
 
+	productIDs = body.ProductIDs
+
 	return
 }
 
-// MarshalJSON MarshalJSON interface redefinition
-func (r nullInt64) MarshalJSON() ([]byte, error) {
-	if r.Valid {
-		return json.Marshal(r.Int64)
+// newBulkInfo - ответ по запрошенным ID в порядке запроса, повторы в not_found не дублируются
+func newBulkInfo(productIDs []uint32, products map[uint32]*Catalog) (info BulkInfo) {
+	info.Items = products
+	info.NotFound = []uint32{}
+	seen := make(map[uint32]bool, len(productIDs))
+	for _, productID := range productIDs {
+		if _, found := products[productID]; found || seen[productID] {
+			continue
+		}
+		seen[productID] = true
+		info.NotFound = append(info.NotFound, productID)
 	}
 
-	return json.Marshal(0)
-
+	return
 }
 
-// MarshalJSON MarshalJSON interface redefinition
-func (r nullFloat64) MarshalJSON() ([]byte, error) {
-	if r.Valid {
-		return json.Marshal(r.Float64)
+// userGroups - группы пользователя из ?user_groups=2,5, нет параметра - все типы цен
+func userGroups(request *http.Request) (groups []uint64, errorMessage error) {
+	value := request.URL.Query().Get("user_groups")
+	if value == "" {
+		return
 	}
 
-	return json.Marshal(0)
+	for _, group := range strings.Split(value, ",") {
+		groupID, err := strconv.ParseUint(strings.TrimSpace(group), 10, 64)
+		if err != nil {
+			errorMessage = errors.New("invalid user_groups " + value)
+			return
+		}
+		groups = append(groups, groupID)
+	}
 
+	return
 }
 
-// MarshalJSON MarshalJSON interface redefinition
-func (r nullString) MarshalJSON() ([]byte, error) {
-	if r.Valid {
-		return json.Marshal(r.String)
+// setPrices - цены по коду типа цены, цена продукта - базовая за единицу (или первая видимая)
+func (catalog *Catalog) setPrices(prices []Price) {
+	catalog.Prices = make(map[string][]Price)
+	var unit *Price
+	for index, price := range prices {
+		catalog.Prices[price.Code] = append(catalog.Prices[price.Code], price)
+
+		if price.QuantityFrom.Valid && price.QuantityFrom.Int64 > 1 {
+			continue
+		}
+		if unit == nil || (price.Base.String == "Y" && unit.Base.String != "Y") {
+			unit = &prices[index]
+		}
 	}
 
-	return json.Marshal("")
-
+	if unit != nil {
+		catalog.Price.Float64 = unit.Price
+		catalog.Price.Valid = true
+		catalog.Currency = unit.Currency
+	}
 }
 
-// MarshalJSON MarshalJSON interface redefinition
-func (r bitrixBool) MarshalJSON() ([]byte, error) {
-	if r.String == "N" {
-		return json.Marshal(false)
+func (catalog *Catalog) haveOffers() (have bool) {
+	if len(catalog.Offers) > 0 {
+		have = true
 	}
 
-	return json.Marshal(true)
-
+	return
 }
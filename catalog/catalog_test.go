@@ -0,0 +1,63 @@
+package catalog
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+// testRepository - каталог с остатками только у товаров из stores
+type testRepository struct {
+	Repository
+	stores map[uint32][]StoreAmount
+}
+
+func (repo *testRepository) Stores(productIDs []uint32) (map[uint32][]StoreAmount, error) {
+	stores := make(map[uint32][]StoreAmount)
+	for _, productID := range productIDs {
+		if productStores, found := repo.stores[productID]; found {
+			stores[productID] = productStores
+		}
+	}
+
+	return stores, nil
+}
+
+func TestStores(t *testing.T) {
+	handler := NewHandler(&testRepository{stores: map[uint32][]StoreAmount{
+		10: {{Store: Store{ID: 1, Address: "Тверская, 1"}, Amount: 3}, {Store: Store{ID: 2}}},
+	}})
+
+	tests := []struct {
+		path   string
+		status int
+		body   string
+	}{
+		{"/catalog/10/stores/", http.StatusOK, ""},
+		{"/catalog/11/stores/", http.StatusBadRequest, "product 11 not found"},
+	}
+
+	for _, test := range tests {
+		response := httptest.NewRecorder()
+		handler.Stores(response, httptest.NewRequest(http.MethodGet, test.path, nil))
+
+		if response.Code != test.status {
+			t.Errorf("%s: status = %d, want %d", test.path, response.Code, test.status)
+		}
+		if test.body != "" && response.Body.String() != test.body {
+			t.Errorf("%s: body = %q, want %q", test.path, response.Body.String(), test.body)
+		}
+	}
+
+	response := httptest.NewRecorder()
+	handler.Stores(response, httptest.NewRequest(http.MethodGet, "/catalog/10/stores/", nil))
+	var stores []StoreAmount
+	err := json.Unmarshal(response.Body.Bytes(), &stores)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(stores) != 2 || stores[0].Amount != 3 || stores[1].Amount != 0 {
+		t.Errorf("stores = %+v, want 2 stores with amounts 3 and 0", stores)
+	}
+}
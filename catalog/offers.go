@@ -0,0 +1,253 @@
+package catalog
+
+import (
+	"sort"
+
+	"github.com/jmoiron/sqlx"
+
+	"../internal/database"
+	"../internal/iblock"
+)
+
+// Offer - торговое предложение товара с данными каталога и значениями свойств дерева предложений,
+// у множественного свойства значений несколько
+type Offer struct {
+	ID      uint64                  `db:"ID" json:"id"`
+	Name    string                  `db:"NAME" json:"name"`
+	Code    database.NullString     `db:"CODE" json:"code"`
+	XMLID   database.NullString     `db:"XML_ID" json:"xml_id"`
+	Active  database.Bool           `db:"ACTIVE" json:"active"`
+	Sort    uint64                  `db:"SORT" json:"sort"`
+	Catalog *Catalog                `json:"catalog"`
+	Props   map[string][]*TreeValue `json:"props"`
+}
+
+// TreeValue - значение свойства дерева предложений
+type TreeValue struct {
+	ID    uint64 `json:"id"`
+	Value string `json:"value"`
+	XMLID string `json:"xml_id"`
+	sort  int
+}
+
+// TreeProperty - свойство, по которому покупатель выбирает предложение (размер, цвет)
+type TreeProperty struct {
+	ID     uint64       `json:"id"`
+	Code   string       `json:"code"`
+	Name   string       `json:"name"`
+	Values []*TreeValue `json:"values"`
+}
+
+// Combination - значения свойств дерева у предложения: код свойства -> xml_id значений
+type Combination struct {
+	OfferID   uint64              `json:"offer_id"`
+	Values    map[string][]string `json:"values"`
+	Available bool                `json:"available"`
+}
+
+// Offers - предложения товара и матрица выбора по свойствам дерева
+type Offers struct {
+	ProductID    uint32          `json:"product_id"`
+	Items        []*Offer        `json:"items"`
+	Tree         []*TreeProperty `json:"tree"`
+	Combinations []*Combination  `json:"combinations"`
+}
+
+// Offers - предложения товара. tree - коды свойств дерева, пусто - все одиночные списки
+// и справочники инфоблока предложений
+func (repo *repository) Offers(productID uint32, tree []string) (result Offers, errorMessage error) {
+	result = Offers{ProductID: productID, Items: []*Offer{}, Tree: []*TreeProperty{}, Combinations: []*Combination{}}
+
+	offerIDs, errorMessage := repo.offers([]uint32{productID})
+	if errorMessage != nil || len(offerIDs[productID]) == 0 {
+		return
+	}
+	ids := offerIDs[productID]
+
+	query, args, err := sqlx.In("SELECT ID, NAME, CODE, XML_ID, ACTIVE, SORT, IBLOCK_ID"+
+		" FROM b_iblock_element"+
+		" WHERE ID IN (?)"+
+		" ORDER BY SORT, ID", ids)
+	if err != nil {
+		errorMessage = err
+		return
+	}
+
+	var rows []struct {
+		Offer
+		IblockID uint64 `db:"IBLOCK_ID"`
+	}
+	errorMessage = repo.conn.Select(&rows, query, args...)
+	if errorMessage != nil || len(rows) == 0 {
+		return
+	}
+
+	products, errorMessage := repo.Products(ids, nil)
+	if errorMessage != nil {
+		return
+	}
+
+	byID := make(map[uint64]*Offer, len(rows))
+	elementIDs := make(map[uint64][]uint64)
+	for index := range rows {
+		offer := &rows[index].Offer
+		offer.Catalog = products[uint32(offer.ID)]
+		offer.Props = make(map[string][]*TreeValue)
+		byID[offer.ID] = offer
+		elementIDs[rows[index].IblockID] = append(elementIDs[rows[index].IblockID], offer.ID)
+		result.Items = append(result.Items, offer)
+	}
+
+	treeProperties, errorMessage := repo.treeProperties(elementIDs, tree)
+	if errorMessage != nil {
+		return
+	}
+
+	result.Tree, errorMessage = repo.fillTree(byID, elementIDs, treeProperties)
+	if errorMessage != nil {
+		return
+	}
+
+	for _, offer := range result.Items {
+		combination := &Combination{
+			OfferID:   offer.ID,
+			Values:    make(map[string][]string, len(offer.Props)),
+			Available: offer.Active.IsTrue() && offer.Catalog != nil && offer.Catalog.Avaliable.IsTrue(),
+		}
+		for code, values := range offer.Props {
+			for _, value := range values {
+				combination.Values[code] = append(combination.Values[code], value.XMLID)
+			}
+		}
+		result.Combinations = append(result.Combinations, combination)
+	}
+
+	return
+}
+
+// treeProperties - свойства дерева предложений по инфоблокам предложений
+func (repo *repository) treeProperties(elementIDs map[uint64][]uint64, tree []string) (byIblock map[uint64][]*iblock.Property, errorMessage error) {
+	byIblock = make(map[uint64][]*iblock.Property)
+
+	var iblockIDs []uint64
+	for iblockID := range elementIDs {
+		iblockIDs = append(iblockIDs, iblockID)
+	}
+	properties, errorMessage := iblock.IblockProperties(repo.conn, iblockIDs)
+	if errorMessage != nil {
+		return
+	}
+
+	links := make(map[uint64]bool)
+	for _, property := range repo.skus {
+		links[property.ID] = true
+	}
+	codes := make(map[string]bool, len(tree))
+	for _, code := range tree {
+		codes[code] = true
+	}
+
+	for index := range properties {
+		property := &properties[index]
+		if links[property.ID] {
+			continue
+		}
+		if len(codes) > 0 {
+			if !codes[property.Key()] {
+				continue
+			}
+		} else if property.Multiple.IsTrue() || (property.Type != "L" && property.UserType.String != "directory") {
+			continue
+		}
+		byIblock[property.IblockID] = append(byIblock[property.IblockID], property)
+	}
+
+	return
+}
+
+// fillTree - значения свойств дерева у предложений и список встречающихся значений по свойствам
+func (repo *repository) fillTree(byID map[uint64]*Offer, elementIDs map[uint64][]uint64, byIblock map[uint64][]*iblock.Property) (tree []*TreeProperty, errorMessage error) {
+	tree = []*TreeProperty{}
+
+	values, errorMessage := iblock.ElementValues(repo.conn, byIblock, elementIDs)
+	if errorMessage != nil {
+		return
+	}
+
+	properties := make(map[uint64]*iblock.Property)
+	var order []*iblock.Property
+	for _, list := range byIblock {
+		for _, property := range list {
+			properties[property.ID] = property
+			order = append(order, property)
+		}
+	}
+
+	var enumIDs []uint64
+	for _, value := range values {
+		if property, found := properties[value.PropertyID]; found && property.Type == "L" {
+			enumIDs = append(enumIDs, value.EnumID())
+		}
+	}
+	enums, errorMessage := iblock.FindEnums(repo.conn, enumIDs)
+	if errorMessage != nil {
+		return
+	}
+
+	byCode := make(map[string]*TreeProperty)
+	seen := make(map[string]map[string]bool)
+	for _, value := range values {
+		property, found := properties[value.PropertyID]
+		if !found {
+			continue
+		}
+		offer, found := byID[value.ElementID]
+		if !found {
+			continue
+		}
+
+		treeValue := &TreeValue{Value: value.Value.String, XMLID: value.Value.String}
+		if property.Type == "L" {
+			enum, found := enums[value.EnumID()]
+			if !found {
+				continue
+			}
+			treeValue = &TreeValue{ID: enum.ID, Value: enum.Value, XMLID: enum.XMLID, sort: enum.Sort}
+		}
+
+		code := property.Key()
+		offer.Props[code] = append(offer.Props[code], treeValue)
+
+		treeProperty, found := byCode[code]
+		if !found {
+			treeProperty = &TreeProperty{ID: property.ID, Code: code, Name: property.Name, Values: []*TreeValue{}}
+			byCode[code] = treeProperty
+			seen[code] = make(map[string]bool)
+		}
+		if !seen[code][treeValue.XMLID] {
+			seen[code][treeValue.XMLID] = true
+			treeProperty.Values = append(treeProperty.Values, treeValue)
+		}
+	}
+
+	// свойства в порядке сортировки инфоблока, значения списков - в порядке сортировки вариантов
+	for _, property := range order {
+		treeProperty, found := byCode[property.Key()]
+		if !found {
+			continue
+		}
+		sort.SliceStable(treeProperty.Values, func(i, j int) bool {
+			return treeProperty.Values[i].sort < treeProperty.Values[j].sort
+		})
+		tree = append(tree, treeProperty)
+	}
+	for _, offer := range byID {
+		for _, values := range offer.Props {
+			sort.SliceStable(values, func(i, j int) bool {
+				return values[i].sort < values[j].sort
+			})
+		}
+	}
+
+	return
+}
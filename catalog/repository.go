@@ -0,0 +1,212 @@
+package catalog
+
+import (
+	"strings"
+
+	"github.com/jmoiron/sqlx"
+
+	"../internal/iblock"
+)
+
+// Repository - хранилище товаров каталога
+type Repository interface {
+	Product(productID uint32, userGroups []uint64) (Catalog, error)
+	Products(productIDs []uint32, userGroups []uint64) (map[uint32]*Catalog, error)
+	Prices(productIDs []uint32, userGroups []uint64) (map[uint32][]Price, error)
+	Offers(productID uint32, tree []string) (Offers, error)
+	Stores(productIDs []uint32) (map[uint32][]StoreAmount, error)
+}
+
+type repository struct {
+	conn *sqlx.DB
+	// skus - свойство CML2_LINK инфоблока предложений по ID инфоблока товаров
+	skus map[uint64]iblock.Property
+}
+
+var fields = []string{
+	"p.ID",
+	"p.AVAILABLE",
+	"p.QUANTITY",
+	"p.QUANTITY_RESERVED",
+	"p.WEIGHT",
+	"p.WIDTH",
+	"p.LENGTH",
+	"p.HEIGHT",
+	"p.MEASURE",
+	"p.TYPE",
+	"p.VAT_INCLUDED",
+	"p.PRICE_TYPE",
+	"p.WITHOUT_ORDER",
+	"p.SELECT_BEST_PRICE",
+}
+
+// NewRepository - хранилище каталога поверх общего пула соединений. Инфоблоки предложений
+// читаются из b_catalog_iblock один раз при создании
+func NewRepository(conn *sqlx.DB) (Repository, error) {
+	skus, err := loadSKUs(conn)
+	if err != nil {
+		return nil, err
+	}
+
+	return &repository{conn: conn, skus: skus}, nil
+}
+
+// Product - товар со всеми ценами, userGroups - только типы цен, видимые группам (пусто - все)
+func (repo *repository) Product(productID uint32, userGroups []uint64) (catalog Catalog, errorMessage error) {
+	query := selectProduct() + " WHERE p.ID = ?"
+
+	err := repo.conn.Get(&catalog, query, productID)
+	if err != nil {
+		errorMessage = err
+		return
+	}
+
+	prices, err := repo.Prices([]uint32{productID}, userGroups)
+	if err != nil {
+		errorMessage = err
+		return
+	}
+	catalog.setPrices(prices[productID])
+
+	offers, err := repo.offers([]uint32{productID})
+	if err != nil {
+		errorMessage = err
+		return
+	}
+
+	catalog.Offers = offers[productID]
+	if catalog.Offers == nil {
+		catalog.Offers = []uint32{}
+	}
+
+	return
+}
+
+// Products - товары пачкой, без записи в b_catalog_product товара в ответе нет
+func (repo *repository) Products(productIDs []uint32, userGroups []uint64) (products map[uint32]*Catalog, errorMessage error) {
+	products = make(map[uint32]*Catalog, len(productIDs))
+	if len(productIDs) == 0 {
+		return
+	}
+
+	query, args, err := sqlx.In(selectProduct()+" WHERE p.ID IN (?)", productIDs)
+	if err != nil {
+		errorMessage = err
+		return
+	}
+
+	var catalogs []*Catalog
+	errorMessage = repo.conn.Select(&catalogs, query, args...)
+	if errorMessage != nil {
+		return
+	}
+	prices, errorMessage := repo.Prices(productIDs, userGroups)
+	if errorMessage != nil {
+		return
+	}
+	for _, catalog := range catalogs {
+		catalog.Offers = []uint32{}
+		catalog.setPrices(prices[catalog.ID])
+		products[catalog.ID] = catalog
+	}
+
+	offers, errorMessage := repo.offers(productIDs)
+	if errorMessage != nil {
+		return
+	}
+	for productID, productOffers := range offers {
+		if catalog, found := products[productID]; found {
+			catalog.Offers = productOffers
+		}
+	}
+
+	return
+}
+
+// Prices - цены товаров пачкой с кодом типа цены из b_catalog_group. userGroups - только типы цен,
+// которые группы могут видеть по b_catalog_group2group, can_buy - могут ли по ним покупать
+func (repo *repository) Prices(productIDs []uint32, userGroups []uint64) (prices map[uint32][]Price, errorMessage error) {
+	prices = make(map[uint32][]Price, len(productIDs))
+	if len(productIDs) == 0 {
+		return
+	}
+
+	query := "SELECT pr.ID, pr.PRODUCT_ID, pr.CATALOG_GROUP_ID, pr.PRICE, pr.CURRENCY, pr.QUANTITY_FROM, pr.QUANTITY_TO," +
+		" g.NAME AS CODE, g.BASE" +
+		" FROM b_catalog_price pr" +
+		" INNER JOIN b_catalog_group g ON g.ID = pr.CATALOG_GROUP_ID" +
+		" WHERE pr.PRODUCT_ID IN (?)"
+	args := []interface{}{productIDs}
+	if len(userGroups) > 0 {
+		query += " AND pr.CATALOG_GROUP_ID IN (SELECT CATALOG_GROUP_ID FROM b_catalog_group2group WHERE GROUP_ID IN (?) AND BUY = 'N')"
+		args = append(args, userGroups)
+	}
+	query += " ORDER BY g.SORT, pr.CATALOG_GROUP_ID, pr.QUANTITY_FROM"
+
+	query, args, err := sqlx.In(query, args...)
+	if err != nil {
+		errorMessage = err
+		return
+	}
+
+	var rows []Price
+	errorMessage = repo.conn.Select(&rows, query, args...)
+	if errorMessage != nil {
+		return
+	}
+
+	canBuy, errorMessage := repo.buyableGroups(userGroups)
+	if errorMessage != nil {
+		return
+	}
+	for _, price := range rows {
+		price.CanBuy = canBuy == nil || canBuy[price.CatalogGroupID]
+		prices[price.ProductID] = append(prices[price.ProductID], price)
+	}
+
+	return
+}
+
+// buyableGroups - типы цен, по которым группы пользователей могут покупать, nil - группы не заданы
+func (repo *repository) buyableGroups(userGroups []uint64) (canBuy map[uint32]bool, errorMessage error) {
+	if len(userGroups) == 0 {
+		return
+	}
+
+	query, args, err := sqlx.In("SELECT DISTINCT CATALOG_GROUP_ID FROM b_catalog_group2group"+
+		" WHERE GROUP_ID IN (?) AND BUY = 'Y'", userGroups)
+	if err != nil {
+		errorMessage = err
+		return
+	}
+
+	var groupIDs []uint32
+	errorMessage = repo.conn.Select(&groupIDs, query, args...)
+	canBuy = make(map[uint32]bool, len(groupIDs))
+	for _, groupID := range groupIDs {
+		canBuy[groupID] = true
+	}
+
+	return
+}
+
+// SelectRatio - подзапрос коэффициента единицы измерения товара по колонке с его ID, без записи - 1
+func SelectRatio(productID string) string {
+	return "IFNULL((SELECT r.RATIO FROM b_catalog_measure_ratio r WHERE r.PRODUCT_ID = " + productID +
+		" ORDER BY r.IS_DEFAULT DESC, r.ID LIMIT 1), 1)"
+}
+
+func selectProduct() string {
+	selectVat := ", (SELECT RATE FROM b_catalog_vat WHERE ID = p.VAT_ID) AS VAT_RATE"
+	selectMeasure := ", m.ID AS `measure.ID`, m.CODE AS `measure.CODE`, m.MEASURE_TITLE AS `measure.MEASURE_TITLE`," +
+		" m.SYMBOL AS `measure.SYMBOL`, m.SYMBOL_INTL AS `measure.SYMBOL_INTL`"
+	selectRatio := ", " + SelectRatio("p.ID") + " AS RATIO"
+
+	return "SELECT " +
+		strings.Join(fields, ", ") +
+		selectVat +
+		selectMeasure +
+		selectRatio +
+		" FROM b_catalog_product p" +
+		" LEFT JOIN b_catalog_measure m ON m.ID = IFNULL(p.MEASURE, (SELECT ID FROM b_catalog_measure WHERE IS_DEFAULT = 'Y' LIMIT 1))"
+}
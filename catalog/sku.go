@@ -0,0 +1,87 @@
+package catalog
+
+import (
+	"strconv"
+
+	"github.com/jmoiron/sqlx"
+
+	"../internal/iblock"
+)
+
+// loadSKUs - для каждого инфоблока товаров с предложениями свойство привязки предложения к товару
+// (b_catalog_iblock: IBLOCK_ID - инфоблок предложений, PRODUCT_IBLOCK_ID - товаров, SKU_PROPERTY_ID - привязка)
+func loadSKUs(conn *sqlx.DB) (skus map[uint64]iblock.Property, errorMessage error) {
+	skus = make(map[uint64]iblock.Property)
+
+	var catalogs []struct {
+		IblockID        uint64 `db:"IBLOCK_ID"`
+		ProductIblockID uint64 `db:"PRODUCT_IBLOCK_ID"`
+		SKUPropertyID   uint64 `db:"SKU_PROPERTY_ID"`
+	}
+	errorMessage = conn.Select(&catalogs, "SELECT IBLOCK_ID, PRODUCT_IBLOCK_ID, SKU_PROPERTY_ID"+
+		" FROM b_catalog_iblock"+
+		" WHERE PRODUCT_IBLOCK_ID > 0 AND SKU_PROPERTY_ID > 0")
+	if errorMessage != nil {
+		return
+	}
+
+	for _, catalog := range catalogs {
+		properties, err := iblock.FindProperties(conn, strconv.FormatUint(catalog.SKUPropertyID, 10), catalog.IblockID)
+		if err != nil {
+			errorMessage = err
+			return
+		}
+		if len(properties) > 0 {
+			skus[catalog.ProductIblockID] = properties[0]
+		}
+	}
+
+	return
+}
+
+// offers - ID торговых предложений товаров: по инфоблоку товара берем инфоблок предложений
+// и его свойство привязки, с учетом того, как инфоблок хранит значения
+func (repo *repository) offers(productIDs []uint32) (offers map[uint32][]uint32, errorMessage error) {
+	offers = make(map[uint32][]uint32)
+	if len(productIDs) == 0 || len(repo.skus) == 0 {
+		return
+	}
+
+	query, args, err := sqlx.In("SELECT ID, IBLOCK_ID FROM b_iblock_element WHERE ID IN (?)", productIDs)
+	if err != nil {
+		errorMessage = err
+		return
+	}
+
+	var products []struct {
+		ID       uint64 `db:"ID"`
+		IblockID uint64 `db:"IBLOCK_ID"`
+	}
+	errorMessage = repo.conn.Select(&products, query, args...)
+	if errorMessage != nil {
+		return
+	}
+
+	byIblock := make(map[uint64][]uint64)
+	for _, product := range products {
+		if _, found := repo.skus[product.IblockID]; found {
+			byIblock[product.IblockID] = append(byIblock[product.IblockID], product.ID)
+		}
+	}
+
+	for iblockID, ids := range byIblock {
+		property := repo.skus[iblockID]
+		linked, err := iblock.LinkedElements(repo.conn, &property, ids)
+		if err != nil {
+			errorMessage = err
+			return
+		}
+		for productID, offerIDs := range linked {
+			for _, offerID := range offerIDs {
+				offers[uint32(productID)] = append(offers[uint32(productID)], uint32(offerID))
+			}
+		}
+	}
+
+	return
+}
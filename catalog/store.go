@@ -0,0 +1,95 @@
+package catalog
+
+import (
+	"github.com/jmoiron/sqlx"
+
+	"../internal/database"
+)
+
+// Store - склад
+type Store struct {
+	ID          uint32              `db:"ID" json:"id"`
+	Code        database.NullString `db:"CODE" json:"code"`
+	XMLID       database.NullString `db:"XML_ID" json:"xml_id"`
+	Title       database.NullString `db:"TITLE" json:"title"`
+	Address     string              `db:"ADDRESS" json:"address"`
+	Description database.NullString `db:"DESCRIPTION" json:"description"`
+	Latitude    database.NullString `db:"GPS_N" json:"latitude"`
+	Longitude   database.NullString `db:"GPS_S" json:"longitude"`
+	Phone       database.NullString `db:"PHONE" json:"phone"`
+	Email       database.NullString `db:"EMAIL" json:"email"`
+	Schedule    database.NullString `db:"SCHEDULE" json:"schedule"`
+	Sort        int                 `db:"SORT" json:"sort"`
+}
+
+// StoreAmount - остаток товара на складе
+type StoreAmount struct {
+	Store
+	Amount float64 `json:"amount"`
+}
+
+// Stores - остатки товаров по каждому активному складу, склад без записи об остатке - с нулем.
+// Товаров без записи в каталоге в ответе нет
+func (repo *repository) Stores(productIDs []uint32) (stores map[uint32][]StoreAmount, errorMessage error) {
+	stores = make(map[uint32][]StoreAmount, len(productIDs))
+	if len(productIDs) == 0 {
+		return
+	}
+
+	query, args, err := sqlx.In("SELECT ID FROM b_catalog_product WHERE ID IN (?)", productIDs)
+	if err != nil {
+		errorMessage = err
+		return
+	}
+	var found []uint32
+	errorMessage = repo.conn.Select(&found, query, args...)
+	if errorMessage != nil || len(found) == 0 {
+		return
+	}
+
+	var active []Store
+	errorMessage = repo.conn.Select(&active, "SELECT ID, CODE, XML_ID, TITLE, ADDRESS, DESCRIPTION, GPS_N, GPS_S, PHONE, EMAIL, SCHEDULE, SORT"+
+		" FROM b_catalog_store"+
+		" WHERE ACTIVE = 'Y'"+
+		" ORDER BY SORT, ID")
+	if errorMessage != nil {
+		return
+	}
+
+	query, args, err = sqlx.In("SELECT PRODUCT_ID, STORE_ID, AMOUNT"+
+		" FROM b_catalog_store_product"+
+		" WHERE PRODUCT_ID IN (?)", found)
+	if err != nil {
+		errorMessage = err
+		return
+	}
+
+	var amounts []struct {
+		ProductID uint32  `db:"PRODUCT_ID"`
+		StoreID   uint32  `db:"STORE_ID"`
+		Amount    float64 `db:"AMOUNT"`
+	}
+	errorMessage = repo.conn.Select(&amounts, query, args...)
+	if errorMessage != nil {
+		return
+	}
+
+	type key struct {
+		productID uint32
+		storeID   uint32
+	}
+	byKey := make(map[key]float64, len(amounts))
+	for _, amount := range amounts {
+		byKey[key{amount.ProductID, amount.StoreID}] = amount.Amount
+	}
+
+	for _, productID := range found {
+		productStores := make([]StoreAmount, 0, len(active))
+		for _, store := range active {
+			productStores = append(productStores, StoreAmount{Store: store, Amount: byKey[key{productID, store.ID}]})
+		}
+		stores[productID] = productStores
+	}
+
+	return
+}
@@ -0,0 +1,101 @@
+package client
+
+import (
+	"bytes"
+	"encoding/json"
+	"reflect"
+	"testing"
+
+	"../api"
+	"../basket"
+	"../catalog"
+	"../element"
+	"../internal/filter"
+	"../internal/iblock"
+	"../section"
+)
+
+// TestResponseShapes - ответы сервиса разбираются в типы api без лишних и потерянных полей
+func TestResponseShapes(t *testing.T) {
+	product := &catalog.Catalog{
+		ID:     10,
+		Prices: map[string][]catalog.Price{"BASE": {{ID: 1}}},
+		Offers: []uint32{11},
+		Stores: []catalog.StoreAmount{{Store: catalog.Store{ID: 1}, Amount: 5}},
+	}
+	picture := iblock.Picture{File: &iblock.File{ID: 1, Src: "/upload/a.jpg"}}
+	item := element.Element{
+		ID:             1,
+		PreviewPicture: picture,
+		DetailPicture:  picture,
+		Meta:           map[string]string{"title": "Шкаф"},
+		Props:          map[string]*element.Property{"COLOR": {ID: 1, Value: "red"}},
+		Section:        &element.Section{ID: 2},
+		Catalog:        product,
+		Prices:         []catalog.Price{{ID: 1}},
+		DetailPageURL:  "/catalog/shkaf/",
+		ListPageURL:    "/catalog/",
+	}
+	group := section.Section{
+		ID:             2,
+		Picture:        picture,
+		Elements:       []uint64{1},
+		Meta:           map[string]string{"title": "Шкафы"},
+		Props:          map[string]string{"UF_COLOR": "red"},
+		Parent:         &section.Parent{ID: 1},
+		SectionPageURL: "/catalog/shkafy/",
+		ListPageURL:    "/catalog/",
+	}
+	limit := filter.Pagination{Limit: 1, NextPage: new(int)}
+	path := []iblock.PathSection{{ID: 2, SectionPageURL: "/catalog/shkafy/"}}
+
+	tests := []struct {
+		name   string
+		server interface{}
+		client interface{}
+	}{
+		{"basket", basket.Basket{ID: 1}, &api.Basket{}},
+		{"catalog", product, &api.Catalog{}},
+		{"bulk info", catalog.BulkInfo{Items: map[uint32]*catalog.Catalog{10: product}, NotFound: []uint32{12}}, &api.BulkInfo{}},
+		{"offers", catalog.Offers{
+			ProductID:    10,
+			Items:        []*catalog.Offer{{ID: 11, Catalog: product, Props: map[string][]*catalog.TreeValue{"COLOR": {{ID: 1}, {ID: 2}}}}},
+			Tree:         []*catalog.TreeProperty{{ID: 1, Values: []*catalog.TreeValue{{ID: 1}}}},
+			Combinations: []*catalog.Combination{{OfferID: 11, Values: map[string][]string{"COLOR": {"1", "2"}}}},
+		}, &api.Offers{}},
+		{"element", item, &api.Element{}},
+		{"element list", element.ListResult{Items: []*element.Element{&item}, Pagination: limit}, &api.ElementList{}},
+		{"element path", element.Path{Items: path, DetailPageURL: "/catalog/shkaf/"}, &api.ElementPath{}},
+		{"section", group, &api.Section{}},
+		{"section list", section.ListResult{Items: []*section.Section{&group}, Pagination: limit}, &api.SectionList{}},
+		{"section path", section.Path{Items: path, SectionPageURL: "/catalog/shkafy/"}, &api.SectionPath{}},
+		{"tree", section.TreeNode{ID: 1, Children: []*section.TreeNode{{ID: 2}}}, &api.TreeNode{}},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			data, err := json.Marshal(test.server)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			decoder := json.NewDecoder(bytes.NewReader(data))
+			decoder.DisallowUnknownFields()
+			if err := decoder.Decode(test.client); err != nil {
+				t.Fatalf("decode %s: %v", data, err)
+			}
+
+			back, err := json.Marshal(test.client)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			var want, got interface{}
+			json.Unmarshal(data, &want)
+			json.Unmarshal(back, &got)
+			if !reflect.DeepEqual(want, got) {
+				t.Errorf("api type lost fields:\nserver %s\nclient %s", data, back)
+			}
+		})
+	}
+}
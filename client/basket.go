@@ -0,0 +1,114 @@
+package client
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"../api"
+)
+
+// BasketService - методы корзины
+type BasketService struct {
+	client *Client
+}
+
+func basketPath(fuserID uint32) string {
+	return "/basket/" + strconv.FormatUint(uint64(fuserID), 10) + "/"
+}
+
+// Items - все записи корзины пользователя
+func (service *BasketService) Items(ctx context.Context, fuserID uint32) (items []api.Basket, errorMessage error) {
+	errorMessage = service.client.getJSON(ctx, basketPath(fuserID)+"items/", &items)
+
+	return
+}
+
+// Product - запись корзины по продукту, nil если продукта нет в корзине
+func (service *BasketService) Product(ctx context.Context, fuserID uint32, productID uint32) (item *api.Basket, errorMessage error) {
+	path := basketPath(fuserID) + "product/" + strconv.FormatUint(uint64(productID), 10) + "/"
+	body, errorMessage := service.client.do(ctx, http.MethodGet, path, nil, true)
+	if errorMessage != nil || len(body) == 0 {
+		return
+	}
+
+	item = &api.Basket{}
+	errorMessage = json.Unmarshal(body, item)
+
+	return
+}
+
+// Count - количество записей в корзине
+func (service *BasketService) Count(ctx context.Context, fuserID uint32) (count int, errorMessage error) {
+	body, errorMessage := service.client.do(ctx, http.MethodGet, basketPath(fuserID)+"count/", nil, true)
+	if errorMessage != nil {
+		return
+	}
+
+	count, errorMessage = strconv.Atoi(strings.TrimSpace(string(body)))
+
+	return
+}
+
+// Cost - стоимость товаров в корзине
+func (service *BasketService) Cost(ctx context.Context, fuserID uint32) (cost float64, errorMessage error) {
+	body, errorMessage := service.client.do(ctx, http.MethodGet, basketPath(fuserID)+"cost/", nil, true)
+	if errorMessage != nil {
+		return
+	}
+
+	cost, errorMessage = strconv.ParseFloat(strings.TrimSpace(string(body)), 64)
+
+	return
+}
+
+// Weight - вес товаров в корзине в килограммах
+func (service *BasketService) Weight(ctx context.Context, fuserID uint32) (weight float64, errorMessage error) {
+	body, errorMessage := service.client.do(ctx, http.MethodGet, basketPath(fuserID)+"weight/", nil, true)
+	if errorMessage != nil {
+		return
+	}
+
+	weight, errorMessage = strconv.ParseFloat(strings.TrimSpace(string(body)), 64)
+
+	return
+}
+
+// Summary - итоги корзины по валютам
+func (service *BasketService) Summary(ctx context.Context, fuserID uint32) (summaries []api.Summary, errorMessage error) {
+	errorMessage = service.client.getJSON(ctx, basketPath(fuserID)+"summary/", &summaries)
+
+	return
+}
+
+// Add - добавляем товар в корзину, повторов нет - добавление не идемпотентно
+func (service *BasketService) Add(ctx context.Context, fuserID uint32, change api.Change) (items []api.Basket, errorMessage error) {
+	errorMessage = service.client.sendJSON(ctx, http.MethodPost, basketPath(fuserID)+"items/", change, false, &items)
+
+	return
+}
+
+// Update - меняем количество или отложенность товара
+func (service *BasketService) Update(ctx context.Context, fuserID uint32, change api.Change) (items []api.Basket, errorMessage error) {
+	path := basketPath(fuserID) + "product/" + strconv.Itoa(change.ProductID) + "/"
+	errorMessage = service.client.sendJSON(ctx, http.MethodPatch, path, change, true, &items)
+
+	return
+}
+
+// Remove - удаляем товар из корзины, повторов нет: если ответ потерялся, повтор получит 400 "is not in basket"
+func (service *BasketService) Remove(ctx context.Context, fuserID uint32, productID uint32) (items []api.Basket, errorMessage error) {
+	path := basketPath(fuserID) + "product/" + strconv.FormatUint(uint64(productID), 10) + "/"
+	errorMessage = service.client.sendJSON(ctx, http.MethodDelete, path, nil, false, &items)
+
+	return
+}
+
+// Clear - очищаем корзину, повтор безопасен - очистка пустой корзины не ошибка
+func (service *BasketService) Clear(ctx context.Context, fuserID uint32) (items []api.Basket, errorMessage error) {
+	errorMessage = service.client.sendJSON(ctx, http.MethodDelete, basketPath(fuserID)+"items/", nil, true, &items)
+
+	return
+}
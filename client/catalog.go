@@ -0,0 +1,102 @@
+package client
+
+import (
+	"context"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+
+	"../api"
+)
+
+// CatalogService - методы каталога
+type CatalogService struct {
+	client *Client
+}
+
+// DeliveryService - методы расчета доставки
+type DeliveryService struct {
+	client *Client
+}
+
+func catalogPath(productID uint32) string {
+	return "/catalog/" + strconv.FormatUint(uint64(productID), 10) + "/"
+}
+
+// Info - информация по продукту, userGroups - только цены, видимые этим группам пользователей
+func (service *CatalogService) Info(ctx context.Context, productID uint32, userGroups ...uint64) (product api.Catalog, errorMessage error) {
+	path := catalogPath(productID) + "info/" + userGroupsQuery(userGroups)
+	errorMessage = service.client.getJSON(ctx, path, &product)
+
+	return
+}
+
+// BulkInfo - информация по нескольким продуктам, ID без записи в каталоге - в NotFound
+func (service *CatalogService) BulkInfo(ctx context.Context, productIDs []uint32, userGroups ...uint64) (info api.BulkInfo, errorMessage error) {
+	path := "/catalog/info/" + userGroupsQuery(userGroups)
+	body := api.ProductsRequest{ProductIDs: productIDs}
+	errorMessage = service.client.sendJSON(ctx, http.MethodPost, path, body, true, &info)
+
+	return
+}
+
+// Offers - торговые предложения продукта с матрицей выбора, tree - коды свойств дерева предложений
+func (service *CatalogService) Offers(ctx context.Context, productID uint32, tree ...string) (offers api.Offers, errorMessage error) {
+	path := catalogPath(productID) + "offers/"
+	if len(tree) > 0 {
+		path += "?tree=" + url.QueryEscape(strings.Join(tree, ","))
+	}
+	errorMessage = service.client.getJSON(ctx, path, &offers)
+
+	return
+}
+
+// Stores - остатки продукта по активным складам
+func (service *CatalogService) Stores(ctx context.Context, productID uint32) (stores []api.StoreAmount, errorMessage error) {
+	errorMessage = service.client.getJSON(ctx, catalogPath(productID)+"stores/", &stores)
+
+	return
+}
+
+// BulkStores - остатки по складам для нескольких продуктов
+func (service *CatalogService) BulkStores(ctx context.Context, productIDs ...uint32) (stores map[uint32][]api.StoreAmount, errorMessage error) {
+	body := api.ProductsRequest{ProductIDs: productIDs}
+	errorMessage = service.client.sendJSON(ctx, http.MethodPost, "/catalog/stores/", body, true, &stores)
+
+	return
+}
+
+// HaveOffers - есть ли у продукта торговые предложения
+func (service *CatalogService) HaveOffers(ctx context.Context, productID uint32) (have bool, errorMessage error) {
+	body, errorMessage := service.client.do(ctx, http.MethodGet, catalogPath(productID)+"have-offers/", nil, true)
+	if errorMessage != nil {
+		return
+	}
+
+	have, errorMessage = strconv.ParseBool(strings.TrimSpace(string(body)))
+
+	return
+}
+
+// userGroupsQuery - ?user_groups=2,5 для методов с ценами, без групп - пусто
+func userGroupsQuery(userGroups []uint64) string {
+	if len(userGroups) == 0 {
+		return ""
+	}
+
+	groups := make([]string, 0, len(userGroups))
+	for _, group := range userGroups {
+		groups = append(groups, strconv.FormatUint(group, 10))
+	}
+
+	return "?user_groups=" + strings.Join(groups, ",")
+}
+
+// Provide - расчет доставки для зоны (moscow, spb, moscow-obl, spb-obl)
+func (service *DeliveryService) Provide(ctx context.Context, zone string, request api.DeliveryRequest) (result api.DeliveryResult, errorMessage error) {
+	path := "/kse/" + zone + "/calc/"
+	errorMessage = service.client.sendJSON(ctx, http.MethodPost, path, request, true, &result)
+
+	return
+}
@@ -0,0 +1,187 @@
+// Package client - клиент для http api сервиса
+package client
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"io"
+	"io/ioutil"
+	"math/rand"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// Client - клиент сервиса, методы сгруппированы по пакетам сервиса
+type Client struct {
+	Basket   *BasketService
+	Catalog  *CatalogService
+	Delivery *DeliveryService
+	Element  *ElementService
+	Section  *SectionService
+
+	baseURL    string
+	httpClient *http.Client
+	retries    int
+	backoff    time.Duration
+	maxBackoff time.Duration
+}
+
+// Option - настройка клиента
+type Option func(client *Client)
+
+// WithHTTPClient - свой http клиент (таймауты, транспорт)
+func WithHTTPClient(httpClient *http.Client) Option {
+	return func(client *Client) {
+		client.httpClient = httpClient
+	}
+}
+
+// WithRetries - количество повторов и начальная задержка между ними, задержка растет экспоненциально
+func WithRetries(retries int, backoff time.Duration) Option {
+	return func(client *Client) {
+		client.retries = retries
+		client.backoff = backoff
+	}
+}
+
+// WithMaxBackoff - максимальная задержка между повторами
+func WithMaxBackoff(maxBackoff time.Duration) Option {
+	return func(client *Client) {
+		client.maxBackoff = maxBackoff
+	}
+}
+
+// New - создаем клиент для сервиса по адресу baseURL (например http://localhost:9000)
+func New(baseURL string, options ...Option) *Client {
+	client := &Client{
+		baseURL:    strings.TrimRight(baseURL, "/"),
+		httpClient: &http.Client{Timeout: 30 * time.Second},
+		retries:    3,
+		backoff:    100 * time.Millisecond,
+		maxBackoff: 5 * time.Second,
+	}
+	for _, option := range options {
+		option(client)
+	}
+
+	client.Basket = &BasketService{client: client}
+	client.Catalog = &CatalogService{client: client}
+	client.Delivery = &DeliveryService{client: client}
+	client.Element = &ElementService{client: client}
+	client.Section = &SectionService{client: client}
+
+	return client
+}
+
+// do - выполняем запрос с повторами, idempotent разрешает повтор после сетевой ошибки и 5xx/429
+func (client *Client) do(ctx context.Context, method string, path string, body interface{}, idempotent bool) (result []byte, errorMessage error) {
+	var payload []byte
+	if body != nil {
+		payload, errorMessage = json.Marshal(body)
+		if errorMessage != nil {
+			return
+		}
+	}
+
+	url := client.baseURL + path
+	for attempt := 0; ; attempt++ {
+		var retry bool
+		result, retry, errorMessage = client.send(ctx, method, url, payload)
+		if errorMessage == nil || !retry || !idempotent || attempt >= client.retries {
+			return
+		}
+
+		timer := time.NewTimer(client.delay(attempt))
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			errorMessage = ctx.Err()
+			return
+		case <-timer.C:
+		}
+	}
+}
+
+// send - одна попытка запроса, retry - можно ли повторить при ошибке
+func (client *Client) send(ctx context.Context, method string, url string, payload []byte) (result []byte, retry bool, errorMessage error) {
+	var reader io.Reader
+	if payload != nil {
+		reader = bytes.NewReader(payload)
+	}
+
+	request, errorMessage := http.NewRequestWithContext(ctx, method, url, reader)
+	if errorMessage != nil {
+		return
+	}
+	if payload != nil {
+		request.Header.Set("Content-Type", "application/json")
+	}
+
+	response, err := client.httpClient.Do(request)
+	if err != nil {
+		errorMessage = err
+		retry = ctx.Err() == nil
+		return
+	}
+	defer response.Body.Close()
+
+	result, err = ioutil.ReadAll(response.Body)
+	if err != nil {
+		errorMessage = err
+		retry = true
+		return
+	}
+
+	if response.StatusCode >= http.StatusBadRequest {
+		errorMessage = &Error{
+			StatusCode: response.StatusCode,
+			Method:     method,
+			URL:        url,
+			Message:    strings.TrimSpace(string(result)),
+		}
+		retry = response.StatusCode >= http.StatusInternalServerError ||
+			response.StatusCode == http.StatusTooManyRequests
+		result = nil
+	}
+
+	return
+}
+
+// delay - экспоненциальная задержка с джиттером
+func (client *Client) delay(attempt int) time.Duration {
+	delay := client.backoff << uint(attempt)
+	if delay <= 0 || delay > client.maxBackoff {
+		delay = client.maxBackoff
+	}
+	if delay <= 0 {
+		return 0
+	}
+
+	return delay/2 + time.Duration(rand.Int63n(int64(delay/2)+1))
+}
+
+// getJSON - GET запрос с разбором json ответа
+func (client *Client) getJSON(ctx context.Context, path string, result interface{}) (errorMessage error) {
+	body, errorMessage := client.do(ctx, http.MethodGet, path, nil, true)
+	if errorMessage != nil {
+		return
+	}
+
+	errorMessage = json.Unmarshal(body, result)
+
+	return
+}
+
+// sendJSON - запрос с телом и разбором json ответа
+func (client *Client) sendJSON(ctx context.Context, method string, path string, body interface{}, idempotent bool, result interface{}) (errorMessage error) {
+	response, errorMessage := client.do(ctx, method, path, body, idempotent)
+	if errorMessage != nil {
+		return
+	}
+
+	errorMessage = json.Unmarshal(response, result)
+
+	return
+}
@@ -0,0 +1,170 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"../api"
+)
+
+// testServer - сервер, который отвечает статусами из statuses по очереди (последний повторяется),
+// на 200 отдает body, attempts - сколько запросов пришло
+func testServer(t *testing.T, body string, statuses ...int) (server *httptest.Server, attempts *int32) {
+	attempts = new(int32)
+	server = httptest.NewServer(http.HandlerFunc(func(response http.ResponseWriter, request *http.Request) {
+		attempt := int(atomic.AddInt32(attempts, 1)) - 1
+		if attempt >= len(statuses) {
+			attempt = len(statuses) - 1
+		}
+
+		response.WriteHeader(statuses[attempt])
+		if statuses[attempt] == http.StatusOK {
+			response.Write([]byte(body))
+			return
+		}
+		response.Write([]byte("error " + http.StatusText(statuses[attempt])))
+	}))
+	t.Cleanup(server.Close)
+
+	return
+}
+
+func TestRetry(t *testing.T) {
+	tests := []struct {
+		name     string
+		statuses []int
+		attempts int32
+		err      error
+	}{
+		{"5xx then ok", []int{http.StatusServiceUnavailable, http.StatusBadGateway, http.StatusOK}, 3, nil},
+		{"429 then ok", []int{http.StatusTooManyRequests, http.StatusOK}, 2, nil},
+		{"retries exhausted", []int{http.StatusInternalServerError}, 4, ErrServer},
+		{"too many requests exhausted", []int{http.StatusTooManyRequests}, 4, ErrTooManyRequests},
+		{"4xx not retried", []int{http.StatusBadRequest}, 1, ErrBadRequest},
+		{"404 not retried", []int{http.StatusNotFound}, 1, ErrNotFound},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			server, attempts := testServer(t, "[]", test.statuses...)
+			client := New(server.URL, WithRetries(3, time.Millisecond), WithMaxBackoff(5*time.Millisecond))
+
+			_, err := client.Basket.Items(context.Background(), 1)
+			if !errors.Is(err, test.err) || (test.err == nil && err != nil) {
+				t.Fatalf("err = %v, want %v", err, test.err)
+			}
+			if *attempts != test.attempts {
+				t.Errorf("attempts = %d, want %d", *attempts, test.attempts)
+			}
+		})
+	}
+}
+
+func TestNotIdempotent(t *testing.T) {
+	server, attempts := testServer(t, "[]", http.StatusServiceUnavailable, http.StatusOK)
+	client := New(server.URL, WithRetries(3, time.Millisecond))
+
+	_, err := client.Basket.Add(context.Background(), 1, api.Change{ProductID: 10, Quantity: 1})
+	if !errors.Is(err, ErrServer) {
+		t.Fatalf("Add err = %v, want ErrServer", err)
+	}
+	if *attempts != 1 {
+		t.Errorf("Add attempts = %d, want 1", *attempts)
+	}
+
+	server, attempts = testServer(t, "[]", http.StatusBadGateway, http.StatusOK)
+	client = New(server.URL, WithRetries(3, time.Millisecond))
+
+	_, err = client.Basket.Remove(context.Background(), 1, 10)
+	if !errors.Is(err, ErrServer) {
+		t.Fatalf("Remove err = %v, want ErrServer", err)
+	}
+	if *attempts != 1 {
+		t.Errorf("Remove attempts = %d, want 1", *attempts)
+	}
+}
+
+func TestContextCancel(t *testing.T) {
+	server, attempts := testServer(t, "[]", http.StatusServiceUnavailable)
+	client := New(server.URL, WithRetries(5, time.Hour), WithMaxBackoff(time.Hour))
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+
+	started := time.Now()
+	_, err := client.Basket.Items(ctx, 1)
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("err = %v, want context.DeadlineExceeded", err)
+	}
+	if elapsed := time.Since(started); elapsed > time.Second {
+		t.Errorf("returned after %v, backoff did not stop on cancel", elapsed)
+	}
+	if *attempts != 1 {
+		t.Errorf("attempts = %d, want 1", *attempts)
+	}
+}
+
+func TestBackoff(t *testing.T) {
+	client := New("http://localhost", WithRetries(3, 100*time.Millisecond), WithMaxBackoff(time.Second))
+
+	tests := []struct {
+		attempt  int
+		min, max time.Duration
+	}{
+		{0, 50 * time.Millisecond, 100 * time.Millisecond},
+		{1, 100 * time.Millisecond, 200 * time.Millisecond},
+		{2, 200 * time.Millisecond, 400 * time.Millisecond},
+		{5, 500 * time.Millisecond, time.Second},
+		{70, 500 * time.Millisecond, time.Second},
+	}
+
+	for _, test := range tests {
+		for i := 0; i < 20; i++ {
+			delay := client.delay(test.attempt)
+			if delay < test.min || delay > test.max {
+				t.Fatalf("delay(%d) = %v, want %v..%v", test.attempt, delay, test.min, test.max)
+			}
+		}
+	}
+}
+
+func TestError(t *testing.T) {
+	tests := []struct {
+		status int
+		err    error
+	}{
+		{http.StatusBadRequest, ErrBadRequest},
+		{http.StatusUnprocessableEntity, ErrBadRequest},
+		{http.StatusNotFound, ErrNotFound},
+		{http.StatusMethodNotAllowed, ErrMethodNotAllowed},
+		{http.StatusConflict, ErrConflict},
+		{http.StatusTooManyRequests, ErrTooManyRequests},
+		{http.StatusInternalServerError, ErrServer},
+		{http.StatusGatewayTimeout, ErrServer},
+	}
+
+	for _, test := range tests {
+		server, _ := testServer(t, "", test.status)
+		client := New(server.URL, WithRetries(0, 0))
+
+		_, err := client.Catalog.Info(context.Background(), 10)
+		if !errors.Is(err, test.err) {
+			t.Errorf("status %d: err = %v, want %v", test.status, err, test.err)
+		}
+
+		var serviceError *Error
+		if !errors.As(err, &serviceError) {
+			t.Fatalf("status %d: err %T is not *Error", test.status, err)
+		}
+		if serviceError.StatusCode != test.status || serviceError.Method != http.MethodGet ||
+			serviceError.URL != server.URL+"/catalog/10/info/" ||
+			serviceError.Message != "error "+http.StatusText(test.status) {
+			t.Errorf("status %d: error = %+v", test.status, serviceError)
+		}
+	}
+}
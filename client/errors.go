@@ -0,0 +1,49 @@
+package client
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+)
+
+// Ошибки по классам http статусов, проверяются через errors.Is
+var (
+	ErrBadRequest       = errors.New("bad request")
+	ErrNotFound         = errors.New("not found")
+	ErrMethodNotAllowed = errors.New("method not allowed")
+	ErrConflict         = errors.New("conflict")
+	ErrTooManyRequests  = errors.New("too many requests")
+	ErrServer           = errors.New("server error")
+)
+
+// Error - сервис ответил кодом ошибки
+type Error struct {
+	StatusCode int
+	Method     string
+	URL        string
+	Message    string
+}
+
+func (err *Error) Error() string {
+	return err.Method + " " + err.URL + ": " + strconv.Itoa(err.StatusCode) + " " + err.Message
+}
+
+// Unwrap - класс ошибки по статусу
+func (err *Error) Unwrap() error {
+	switch {
+	case err.StatusCode == http.StatusNotFound:
+		return ErrNotFound
+	case err.StatusCode == http.StatusMethodNotAllowed:
+		return ErrMethodNotAllowed
+	case err.StatusCode == http.StatusConflict:
+		return ErrConflict
+	case err.StatusCode == http.StatusTooManyRequests:
+		return ErrTooManyRequests
+	case err.StatusCode >= http.StatusInternalServerError:
+		return ErrServer
+	case err.StatusCode >= http.StatusBadRequest:
+		return ErrBadRequest
+	}
+
+	return nil
+}
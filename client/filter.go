@@ -0,0 +1,217 @@
+package client
+
+import (
+	"net/url"
+	"strconv"
+	"strings"
+)
+
+// ListRequest - тело запроса для /element/list/ и /section/list/
+type ListRequest struct {
+	Filter map[string]interface{} `json:"filter,omitempty"`
+	Params map[string]interface{} `json:"params,omitempty"`
+	Selection
+}
+
+// Selection - select и expand: выбранные колонки и подгружаемые связи.
+// Relations == nil - связи по умолчанию, пустой список - без связей
+type Selection struct {
+	Fields    []string  `json:"select,omitempty"`
+	Relations *[]string `json:"expand,omitempty"`
+}
+
+// Select - только эти колонки записи (ID отдается всегда)
+func Select(fields ...string) *Selection {
+	return &Selection{Fields: fields}
+}
+
+// Expand - подгружаемые связи вместо связей по умолчанию
+func (selection *Selection) Expand(relations ...string) *Selection {
+	if relations == nil {
+		relations = []string{}
+	}
+	selection.Relations = &relations
+
+	return selection
+}
+
+// query - select и expand строкой запроса для чтения одной записи
+func (selection *Selection) query() string {
+	if selection == nil {
+		return ""
+	}
+
+	values := url.Values{}
+	if len(selection.Fields) > 0 {
+		values.Set("select", strings.Join(selection.Fields, ","))
+	}
+	if selection.Relations != nil {
+		values.Set("expand", strings.Join(*selection.Relations, ","))
+	}
+	if len(values) == 0 {
+		return ""
+	}
+
+	return "?" + values.Encode()
+}
+
+// NewListRequest - пустой запрос списка
+func NewListRequest() *ListRequest {
+	return &ListRequest{
+		Filter: make(map[string]interface{}),
+		Params: make(map[string]interface{}),
+	}
+}
+
+// Where - произвольное условие фильтра
+func (list *ListRequest) Where(key string, value string) *ListRequest {
+	list.Filter[key] = value
+
+	return list
+}
+
+// WhereIn - условие по списку значений (IN, с префиксом ! - NOT IN)
+func (list *ListRequest) WhereIn(key string, values ...string) *ListRequest {
+	list.Filter[key] = values
+
+	return list
+}
+
+// Property - фильтр элементов по свойству: код или ID свойства, для списков - XML_ID вариантов.
+// Несколько значений - любое из них
+func (list *ListRequest) Property(code string, values ...string) *ListRequest {
+	if len(values) == 1 {
+		return list.Where("PROPERTY_"+code, values[0])
+	}
+
+	return list.WhereIn("PROPERTY_"+code, values...)
+}
+
+// WhereNull - поле не заполнено (с префиксом ! - заполнено)
+func (list *ListRequest) WhereNull(key string) *ListRequest {
+	list.Filter[key] = nil
+
+	return list
+}
+
+// Between - значение в диапазоне (><) включительно
+func (list *ListRequest) Between(field string, from string, to string) *ListRequest {
+	list.Filter["><"+field] = []string{from, to}
+
+	return list
+}
+
+// Or - вложенная группа условий, объединенных через OR
+func (list *ListRequest) Or(groups ...*ListRequest) *ListRequest {
+	return list.group("OR", groups)
+}
+
+// And - вложенная группа условий, объединенных через AND
+func (list *ListRequest) And(groups ...*ListRequest) *ListRequest {
+	return list.group("AND", groups)
+}
+
+func (list *ListRequest) group(logic string, groups []*ListRequest) *ListRequest {
+	nested := map[string]interface{}{"LOGIC": logic}
+	for index, group := range groups {
+		nested[strconv.Itoa(index)] = group.Filter
+	}
+	list.Filter["GROUP_"+strconv.Itoa(len(list.Filter))] = nested
+
+	return list
+}
+
+// ID - фильтр по ID
+func (list *ListRequest) ID(id uint64) *ListRequest {
+	return list.Where("ID", strconv.FormatUint(id, 10))
+}
+
+// Code - фильтр по символьному коду
+func (list *ListRequest) Code(code string) *ListRequest {
+	return list.Where("CODE", code)
+}
+
+// XMLID - фильтр по внешнему коду
+func (list *ListRequest) XMLID(xmlID string) *ListRequest {
+	return list.Where("XML_ID", xmlID)
+}
+
+// Name - фильтр по названию
+func (list *ListRequest) Name(name string) *ListRequest {
+	return list.Where("NAME", name)
+}
+
+// IblockID - фильтр по инфоблоку
+func (list *ListRequest) IblockID(iblockID uint64) *ListRequest {
+	return list.Where("IBLOCK_ID", strconv.FormatUint(iblockID, 10))
+}
+
+// SectionID - фильтр по родительскому разделу
+func (list *ListRequest) SectionID(sectionID uint64) *ListRequest {
+	return list.Where("IBLOCK_SECTION_ID", strconv.FormatUint(sectionID, 10))
+}
+
+// Active - фильтр по активности
+func (list *ListRequest) Active(active bool) *ListRequest {
+	if active {
+		return list.Where("ACTIVE", "Y")
+	}
+
+	return list.Where("ACTIVE", "N")
+}
+
+// Limit - количество записей на странице
+func (list *ListRequest) Limit(limit int) *ListRequest {
+	list.Params["LIMIT"] = limit
+
+	return list
+}
+
+// Page - номер страницы с 1
+func (list *ListRequest) Page(page int) *ListRequest {
+	list.Params["PAGE"] = page
+
+	return list
+}
+
+// Offset - смещение от начала выборки
+func (list *ListRequest) Offset(offset int) *ListRequest {
+	list.Params["OFFSET"] = offset
+
+	return list
+}
+
+// Cursor - обход по курсору: пустая строка - первая страница, дальше next_cursor из ответа
+func (list *ListRequest) Cursor(cursor string) *ListRequest {
+	list.Params["CURSOR"] = cursor
+
+	return list
+}
+
+// Order - сортировка по разрешенным полям, например "SORT ASC, NAME DESC"
+func (list *ListRequest) Order(order string) *ListRequest {
+	list.Params["ORDER"] = order
+
+	return list
+}
+
+// Group - группировка по разрешенным полям, нужен Select только из этих полей, expand не передается
+func (list *ListRequest) Group(fields ...string) *ListRequest {
+	list.Params["GROUP"] = fields
+
+	return list
+}
+
+// Select - только эти колонки записей (ID отдается всегда)
+func (list *ListRequest) Select(fields ...string) *ListRequest {
+	list.Fields = fields
+
+	return list
+}
+
+// Expand - подгружаемые связи вместо связей по умолчанию, без аргументов - без связей
+func (list *ListRequest) Expand(relations ...string) *ListRequest {
+	list.Selection.Expand(relations...)
+
+	return list
+}
@@ -0,0 +1,111 @@
+package client
+
+import (
+	"context"
+	"net/http"
+	"net/url"
+	"strconv"
+
+	"../api"
+)
+
+// ElementService - методы элементов инфоблока
+type ElementService struct {
+	client *Client
+}
+
+// SectionService - методы разделов инфоблока
+type SectionService struct {
+	client *Client
+}
+
+// InfoByID - элемент по ID, selection - необязательные select и expand
+func (service *ElementService) InfoByID(ctx context.Context, elementID uint64, selection ...*Selection) (item api.Element, errorMessage error) {
+	errorMessage = service.client.getJSON(ctx, "/element/"+strconv.FormatUint(elementID, 10)+"/info/"+selectionQuery(selection), &item)
+
+	return
+}
+
+// InfoByCode - элемент по символьному коду, selection - необязательные select и expand
+func (service *ElementService) InfoByCode(ctx context.Context, code string, selection ...*Selection) (item api.Element, errorMessage error) {
+	errorMessage = service.client.getJSON(ctx, "/element/"+url.PathEscape(code)+"/info/"+selectionQuery(selection), &item)
+
+	return
+}
+
+// List - элементы по фильтру
+func (service *ElementService) List(ctx context.Context, list *ListRequest) (result api.ElementList, errorMessage error) {
+	errorMessage = service.client.sendJSON(ctx, http.MethodPost, "/element/list/", list, true, &result)
+
+	return
+}
+
+// GetProperties - свойства элемента с типами, ключ - символьный код свойства
+func (service *ElementService) GetProperties(ctx context.Context, elementID uint64) (props map[string]*api.Property, errorMessage error) {
+	errorMessage = service.client.getJSON(ctx, "/element/"+strconv.FormatUint(elementID, 10)+"/props/", &props)
+
+	return
+}
+
+// Path - хлебные крошки элемента и ссылка на элемент
+func (service *ElementService) Path(ctx context.Context, elementID uint64) (path api.ElementPath, errorMessage error) {
+	errorMessage = service.client.getJSON(ctx, "/element/"+strconv.FormatUint(elementID, 10)+"/path/", &path)
+
+	return
+}
+
+// InfoByID - раздел по ID, selection - необязательные select и expand
+func (service *SectionService) InfoByID(ctx context.Context, sectionID uint64, selection ...*Selection) (item api.Section, errorMessage error) {
+	errorMessage = service.client.getJSON(ctx, "/section/"+strconv.FormatUint(sectionID, 10)+"/info/"+selectionQuery(selection), &item)
+
+	return
+}
+
+// InfoByCode - раздел по символьному коду, selection - необязательные select и expand
+func (service *SectionService) InfoByCode(ctx context.Context, code string, selection ...*Selection) (item api.Section, errorMessage error) {
+	errorMessage = service.client.getJSON(ctx, "/section/"+url.PathEscape(code)+"/info/"+selectionQuery(selection), &item)
+
+	return
+}
+
+// List - разделы по фильтру
+func (service *SectionService) List(ctx context.Context, list *ListRequest) (result api.SectionList, errorMessage error) {
+	errorMessage = service.client.sendJSON(ctx, http.MethodPost, "/section/list/", list, true, &result)
+
+	return
+}
+
+// Path - хлебные крошки раздела и ссылка на раздел
+func (service *SectionService) Path(ctx context.Context, sectionID uint64) (path api.SectionPath, errorMessage error) {
+	errorMessage = service.client.getJSON(ctx, "/section/"+strconv.FormatUint(sectionID, 10)+"/path/", &path)
+
+	return
+}
+
+// Tree - дерево разделов инфоблока или раздела query.RootID, вложенные разделы в Children
+func (service *SectionService) Tree(ctx context.Context, query api.TreeQuery) (tree []*api.TreeNode, errorMessage error) {
+	values := url.Values{}
+	if query.IblockID > 0 {
+		values.Set("iblock_id", strconv.FormatUint(query.IblockID, 10))
+	}
+	if query.RootID > 0 {
+		values.Set("root", strconv.FormatUint(query.RootID, 10))
+	}
+	if query.Depth > 0 {
+		values.Set("depth", strconv.FormatUint(query.Depth, 10))
+	}
+	if query.ActiveOnly {
+		values.Set("active", "Y")
+	}
+	errorMessage = service.client.getJSON(ctx, "/section/tree/?"+values.Encode(), &tree)
+
+	return
+}
+
+func selectionQuery(selection []*Selection) string {
+	if len(selection) == 0 {
+		return ""
+	}
+
+	return selection[0].query()
+}
@@ -0,0 +1,211 @@
+package delivery
+
+import (
+	"encoding/json"
+	"errors"
+	"io/ioutil"
+	"math"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"../api"
+	"../basket"
+	"../catalog"
+)
+
+// Request - тело запроса на расчет доставки, тип общий с client
+type Request = api.DeliveryRequest
+
+// Item - товар для расчета, если расчет идет не по корзине
+type Item = api.DeliveryItem
+
+// Result - результат расчета доставки
+type Result = api.DeliveryResult
+
+// line - позиция для расчета: вес в граммах и цена за единицу
+type line struct {
+	productID uint32
+	quantity  float64
+	weight    float64
+	price     float64
+}
+
+// Handler - обработчик расчета доставки
+type Handler struct {
+	baskets  basket.Repository
+	products catalog.Repository
+	tariffs  *tariffStore
+}
+
+// NewHandler - создаем обработчик с хранилищами корзин и каталога и тарифами из файла tariffPath
+func NewHandler(baskets basket.Repository, products catalog.Repository, tariffPath string) (handler *Handler, errorMessage error) {
+	tariffs := &tariffStore{path: tariffPath}
+	errorMessage = tariffs.load()
+	if errorMessage != nil {
+		return
+	}
+
+	handler = &Handler{baskets: baskets, products: products, tariffs: tariffs}
+
+	return
+}
+
+// Provide - расчет стоимости и сроков доставки для зоны из адреса запроса
+func (handler *Handler) Provide(response http.ResponseWriter, request *http.Request) {
+	var deliveryRequest Request
+
+	requestURL := strings.Split(request.RequestURI, "/")
+	zoneCode := requestURL[2]
+
+	body, err := ioutil.ReadAll(request.Body)
+	defer request.Body.Close()
+	if err != nil {
+		response.WriteHeader(http.StatusBadRequest)
+		response.Write([]byte(err.Error()))
+		return
+	}
+	err = json.Unmarshal(body, &deliveryRequest)
+	if err != nil {
+		response.WriteHeader(http.StatusBadRequest)
+		response.Write([]byte(err.Error()))
+		return
+	}
+
+	result, err := handler.calculate(zoneCode, deliveryRequest)
+	if err != nil {
+		response.WriteHeader(http.StatusBadRequest)
+		response.Write([]byte(err.Error()))
+		return
+	}
+
+	resultJSON, _ := json.Marshal(result)
+	response.Header().Set("Content-Type", "application/json")
+	response.WriteHeader(http.StatusOK)
+	response.Write(resultJSON)
+}
+
+func (handler *Handler) calculate(zoneCode string, deliveryRequest Request) (result Result, errorMessage error) {
+	zone, found := handler.tariffs.zone(zoneCode)
+	if !found {
+		errorMessage = errors.New("unknown delivery zone " + zoneCode)
+		return
+	}
+
+	lines, err := handler.getLines(deliveryRequest)
+	if err != nil {
+		errorMessage = err
+		return
+	}
+	if len(lines) == 0 {
+		errorMessage = errors.New("nothing to deliver")
+		return
+	}
+
+	// габариты и вес всех товаров одним запросом
+	productIDs := make([]uint32, 0, len(lines))
+	for _, item := range lines {
+		productIDs = append(productIDs, item.productID)
+	}
+	products, err := handler.products.Products(productIDs, nil)
+	if err != nil {
+		errorMessage = err
+		return
+	}
+
+	var weight, volume float64
+	for _, item := range lines {
+		product, found := products[item.productID]
+		if !found {
+			errorMessage = errors.New("product " + strconv.FormatUint(uint64(item.productID), 10) + " not found")
+			return
+		}
+
+		unitWeight := item.weight
+		if unitWeight == 0 {
+			unitWeight = product.Weight.Float64
+		}
+		if item.price == 0 {
+			item.price = product.Price.Float64
+		}
+
+		quantity := item.quantity
+		weight += unitWeight * quantity
+		volume += product.Width.Float64 * product.Length.Float64 * product.Height.Float64 * quantity
+		result.Cost += item.price * quantity
+	}
+
+	// вес в базе хранится в граммах, габариты в миллиметрах
+	result.Weight = weight / 1000
+	if zone.VolumeRatio > 0 {
+		result.VolumeWeight = volume / 1000 / zone.VolumeRatio
+	}
+
+	billableWeight := math.Max(result.Weight, result.VolumeWeight)
+	if zone.MaxWeight > 0 && billableWeight > zone.MaxWeight {
+		errorMessage = errors.New("weight " + strconv.FormatFloat(billableWeight, 'f', 2, 64) +
+			" exceeds zone limit " + strconv.FormatFloat(zone.MaxWeight, 'f', 2, 64))
+		return
+	}
+
+	surcharge, extraDays := zone.surcharge(billableWeight)
+	result.Price = zone.BasePrice + surcharge
+	if zone.FreeFrom > 0 && result.Cost >= zone.FreeFrom {
+		result.Price = 0
+	}
+	result.Price = math.Round(result.Price*100) / 100
+
+	now := time.Now()
+	result.Zone = zoneCode
+	result.ZoneName = zone.Name
+	result.Currency = zone.Currency
+	result.DaysMin = zone.DaysMin + extraDays
+	result.DaysMax = zone.DaysMax + extraDays
+	result.DateMin = now.AddDate(0, 0, result.DaysMin).Format("2006-01-02")
+	result.DateMax = now.AddDate(0, 0, result.DaysMax).Format("2006-01-02")
+
+	return
+}
+
+// getLines - позиции из корзины пользователя или из явного списка товаров
+func (handler *Handler) getLines(deliveryRequest Request) (lines []line, errorMessage error) {
+	if len(deliveryRequest.Items) > 0 {
+		for _, item := range deliveryRequest.Items {
+			if item.Quantity <= 0 {
+				errorMessage = errors.New("quantity must be positive for product " +
+					strconv.FormatUint(uint64(item.ProductID), 10))
+				return
+			}
+			lines = append(lines, line{productID: item.ProductID, quantity: item.Quantity})
+		}
+
+		return
+	}
+
+	if deliveryRequest.FuserID == 0 {
+		errorMessage = errors.New("fuser_id or items required")
+		return
+	}
+
+	items, err := handler.baskets.Items(deliveryRequest.FuserID)
+	if err != nil {
+		errorMessage = err
+		return
+	}
+
+	for _, item := range items {
+		// отложенные и уже оформленные позиции не доставляем
+		if item.Delay.IsTrue() || !item.CanBuy.IsTrue() || item.OrderID.Valid {
+			continue
+		}
+		lines = append(lines, line{
+			productID: uint32(item.ProductID),
+			quantity:  item.Quantity,
+			weight:    item.Weight,
+			price:     item.Price,
+		})
+	}
+
+	return
+}
@@ -0,0 +1,323 @@
+package delivery
+
+import (
+	"database/sql"
+	"errors"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"../basket"
+	"../catalog"
+	"../internal/database"
+)
+
+const testTariffs = `
+zones:
+  city:
+    name: Город
+    currency: RUB
+    base_price: 300
+    price_per_kg: 15
+    free_from: 5000
+    volume_ratio: 5000
+    max_weight: 200
+    days_min: 1
+    days_max: 2
+    weight_tiers:
+      - max_weight: 5
+        price: 0
+      - max_weight: 30
+        price: 200
+      - max_weight: 100
+        price: 600
+        extra_days: 1
+  flat:
+    name: Без ступеней
+    currency: RUB
+    base_price: 100
+    price_per_kg: 10
+    days_min: 3
+    days_max: 5
+`
+
+func number(value float64) database.NullFloat64 {
+	return database.NullFloat64{NullFloat64: sql.NullFloat64{Float64: value, Valid: true}}
+}
+
+// testProducts - каталог из памяти: вес в граммах, габариты в миллиметрах
+type testProducts struct {
+	products map[uint32]*catalog.Catalog
+	calls    int
+}
+
+func newTestProducts() *testProducts {
+	return &testProducts{products: map[uint32]*catalog.Catalog{
+		// 1 кг, 10x10x10 см
+		1: {ID: 1, Weight: number(1000), Width: number(100), Length: number(100), Height: number(100), Price: number(1000)},
+		// 0.2 кг, но 50x40x30 см - 12 кг объемного веса
+		2: {ID: 2, Weight: number(200), Width: number(500), Length: number(400), Height: number(300), Price: number(500)},
+		// 50 кг без габаритов
+		3: {ID: 3, Weight: number(50000), Price: number(100)},
+	}}
+}
+
+func (repo *testProducts) Product(productID uint32, userGroups []uint64) (catalog.Catalog, error) {
+	repo.calls++
+	return catalog.Catalog{}, errors.New("use Products")
+}
+
+func (repo *testProducts) Products(productIDs []uint32, userGroups []uint64) (map[uint32]*catalog.Catalog, error) {
+	repo.calls++
+	products := make(map[uint32]*catalog.Catalog)
+	for _, id := range productIDs {
+		if product, found := repo.products[id]; found {
+			products[id] = product
+		}
+	}
+
+	return products, nil
+}
+
+func (repo *testProducts) Prices(productIDs []uint32, userGroups []uint64) (map[uint32][]catalog.Price, error) {
+	return nil, nil
+}
+
+func (repo *testProducts) Offers(productID uint32, tree []string) (catalog.Offers, error) {
+	return catalog.Offers{}, nil
+}
+
+func (repo *testProducts) Stores(productIDs []uint32) (map[uint32][]catalog.StoreAmount, error) {
+	return nil, nil
+}
+
+// testBaskets - корзины из памяти
+type testBaskets map[uint32][]basket.Basket
+
+func (baskets testBaskets) Items(fuserID uint32) ([]basket.Basket, error) {
+	return baskets[fuserID], nil
+}
+func (baskets testBaskets) Add(fuserID uint32, change basket.Change) error    { return nil }
+func (baskets testBaskets) Update(fuserID uint32, change basket.Change) error { return nil }
+func (baskets testBaskets) Remove(fuserID uint32, productID int) error        { return nil }
+func (baskets testBaskets) Clear(fuserID uint32) error                        { return nil }
+
+func basketItem(productID int, quantity, weight, price float64) basket.Basket {
+	return basket.Basket{
+		ProductID: productID,
+		Quantity:  quantity,
+		Weight:    weight,
+		Price:     price,
+		CanBuy:    database.NewBool(true),
+		Delay:     database.NewBool(false),
+	}
+}
+
+// writeTariffs - файл тарифов во временной папке
+func writeTariffs(t *testing.T, path string, content string) {
+	t.Helper()
+	if err := ioutil.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func newTestHandler(t *testing.T) (*Handler, *testProducts) {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "delivery.yml")
+	writeTariffs(t, path, testTariffs)
+
+	delayed := basketItem(1, 10, 1000, 1000)
+	delayed.Delay = database.NewBool(true)
+	ordered := basketItem(3, 1, 50000, 100)
+	ordered.OrderID = database.NullInt64{NullInt64: sql.NullInt64{Int64: 1, Valid: true}}
+	baskets := testBaskets{
+		7: {basketItem(1, 2, 1500, 800), delayed, ordered},
+		8: {delayed},
+	}
+
+	products := newTestProducts()
+	handler, err := NewHandler(baskets, products, path)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return handler, products
+}
+
+func TestSurcharge(t *testing.T) {
+	handler, _ := newTestHandler(t)
+	city, _ := handler.tariffs.zone("city")
+	flat, _ := handler.tariffs.zone("flat")
+
+	tests := []struct {
+		zone      Zone
+		weight    float64
+		wantPrice float64
+		wantDays  int
+	}{
+		{city, 0, 0, 0},
+		{city, 5, 0, 0},
+		{city, 5.01, 200, 0},
+		{city, 30, 200, 0},
+		{city, 30.5, 600, 1},
+		{city, 100, 600, 1},
+		{city, 110, 750, 1},
+		{flat, 0, 0, 0},
+		{flat, 2.5, 25, 0},
+	}
+
+	for _, test := range tests {
+		price, days := test.zone.surcharge(test.weight)
+		if price != test.wantPrice || days != test.wantDays {
+			t.Errorf("%s surcharge(%v) = %v, %d days, want %v, %d days", test.zone.Name, test.weight,
+				price, days, test.wantPrice, test.wantDays)
+		}
+	}
+}
+
+func TestCalculate(t *testing.T) {
+	tests := []struct {
+		name        string
+		zone        string
+		request     Request
+		wantPrice   float64
+		wantCost    float64
+		wantWeight  float64
+		wantVolume  float64
+		wantDaysMin int
+		wantDaysMax int
+	}{
+		{
+			name:      "first tier",
+			zone:      "city",
+			request:   Request{Items: []Item{{ProductID: 1, Quantity: 2}}},
+			wantPrice: 300, wantCost: 2000, wantWeight: 2, wantVolume: 0.4, wantDaysMin: 1, wantDaysMax: 2,
+		},
+		{
+			name:      "volume weight wins",
+			zone:      "city",
+			request:   Request{Items: []Item{{ProductID: 2, Quantity: 1}}},
+			wantPrice: 500, wantCost: 500, wantWeight: 0.2, wantVolume: 12, wantDaysMin: 1, wantDaysMax: 2,
+		},
+		{
+			name:      "free from cost",
+			zone:      "city",
+			request:   Request{Items: []Item{{ProductID: 1, Quantity: 3}, {ProductID: 2, Quantity: 4}}},
+			wantPrice: 0, wantCost: 5000, wantWeight: 3.8, wantVolume: 48.6, wantDaysMin: 2, wantDaysMax: 3,
+		},
+		{
+			name:      "tier with extra days",
+			zone:      "city",
+			request:   Request{Items: []Item{{ProductID: 3, Quantity: 1}}},
+			wantPrice: 900, wantCost: 100, wantWeight: 50, wantDaysMin: 2, wantDaysMax: 3,
+		},
+		{
+			name:      "above last tier per kg",
+			zone:      "city",
+			request:   Request{Items: []Item{{ProductID: 3, Quantity: 3}}},
+			wantPrice: 1650, wantCost: 300, wantWeight: 150, wantDaysMin: 2, wantDaysMax: 3,
+		},
+		{
+			name:      "no tiers, no free delivery",
+			zone:      "flat",
+			request:   Request{Items: []Item{{ProductID: 1, Quantity: 100}}},
+			wantPrice: 1100, wantCost: 100000, wantWeight: 100, wantDaysMin: 3, wantDaysMax: 5,
+		},
+		{
+			name:      "basket weight and price, delayed and ordered skipped",
+			zone:      "city",
+			request:   Request{FuserID: 7},
+			wantPrice: 300, wantCost: 1600, wantWeight: 3, wantVolume: 0.4, wantDaysMin: 1, wantDaysMax: 2,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			handler, products := newTestHandler(t)
+			result, err := handler.calculate(test.zone, test.request)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if result.Price != test.wantPrice || result.Cost != test.wantCost {
+				t.Errorf("price %v cost %v, want %v %v", result.Price, result.Cost, test.wantPrice, test.wantCost)
+			}
+			if result.Weight != test.wantWeight || result.VolumeWeight != test.wantVolume {
+				t.Errorf("weight %v volume %v, want %v %v", result.Weight, result.VolumeWeight, test.wantWeight, test.wantVolume)
+			}
+			if result.DaysMin != test.wantDaysMin || result.DaysMax != test.wantDaysMax {
+				t.Errorf("days %d-%d, want %d-%d", result.DaysMin, result.DaysMax, test.wantDaysMin, test.wantDaysMax)
+			}
+			if result.Zone != test.zone || result.Currency != "RUB" {
+				t.Errorf("zone %q currency %q", result.Zone, result.Currency)
+			}
+			if products.calls != 1 {
+				t.Errorf("catalog called %d times, want one bulk call", products.calls)
+			}
+		})
+	}
+}
+
+func TestCalculateErrors(t *testing.T) {
+	tests := []struct {
+		name    string
+		zone    string
+		request Request
+	}{
+		{name: "unknown zone", zone: "mars", request: Request{Items: []Item{{ProductID: 1, Quantity: 1}}}},
+		{name: "unknown product", zone: "city", request: Request{Items: []Item{{ProductID: 1, Quantity: 1}, {ProductID: 99, Quantity: 1}}}},
+		{name: "zero quantity", zone: "city", request: Request{Items: []Item{{ProductID: 1}}}},
+		{name: "negative quantity", zone: "city", request: Request{Items: []Item{{ProductID: 1, Quantity: -1}}}},
+		{name: "no items and no basket", zone: "city", request: Request{}},
+		{name: "nothing to deliver in basket", zone: "city", request: Request{FuserID: 8}},
+		{name: "over zone max weight", zone: "city", request: Request{Items: []Item{{ProductID: 3, Quantity: 5}}}},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			handler, _ := newTestHandler(t)
+			if result, err := handler.calculate(test.zone, test.request); err == nil {
+				t.Errorf("calculate = %+v, want error", result)
+			}
+		})
+	}
+}
+
+func TestTariffReload(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "delivery.yml")
+	writeTariffs(t, path, testTariffs)
+	store := &tariffStore{path: path}
+	if err := store.load(); err != nil {
+		t.Fatal(err)
+	}
+
+	zone, found := store.zone("city")
+	if !found || zone.BasePrice != 300 {
+		t.Fatalf("zone city = %+v, %v", zone, found)
+	}
+
+	// время изменения сдвигаем явно, на некоторых файловых системах точность mtime - секунда
+	touch := func(content string, modTime time.Time) {
+		writeTariffs(t, path, content)
+		if err := os.Chtimes(path, modTime, modTime); err != nil {
+			t.Fatal(err)
+		}
+	}
+	now := time.Now()
+
+	touch("zones:\n  city:\n    base_price: 350\n", now.Add(time.Minute))
+	zone, found = store.zone("city")
+	if !found || zone.BasePrice != 350 {
+		t.Errorf("after change zone city = %+v, %v, want base_price 350", zone, found)
+	}
+	if _, found = store.zone("flat"); found {
+		t.Error("zone removed from file is still found")
+	}
+
+	touch("zones: [broken", now.Add(2*time.Minute))
+	zone, found = store.zone("city")
+	if !found || zone.BasePrice != 350 {
+		t.Errorf("after broken file zone city = %+v, %v, want previous tariffs", zone, found)
+	}
+}
@@ -0,0 +1,117 @@
+package delivery
+
+import (
+	"io/ioutil"
+	"log"
+	"os"
+	"sync"
+	"time"
+
+	yaml "gopkg.in/yaml.v2"
+)
+
+// Tariffs - структура для данных из delivery.yml файла
+type Tariffs struct {
+	Zones map[string]Zone `yaml:"zones"`
+}
+
+// Zone - тариф для одной зоны доставки
+type Zone struct {
+	Name        string       `yaml:"name"`
+	Currency    string       `yaml:"currency"`
+	BasePrice   float64      `yaml:"base_price"`
+	PricePerKg  float64      `yaml:"price_per_kg"`
+	FreeFrom    float64      `yaml:"free_from"`
+	VolumeRatio float64      `yaml:"volume_ratio"`
+	MaxWeight   float64      `yaml:"max_weight"`
+	DaysMin     int          `yaml:"days_min"`
+	DaysMax     int          `yaml:"days_max"`
+	WeightTiers []WeightTier `yaml:"weight_tiers"`
+}
+
+// WeightTier - надбавка к базовой цене для веса до MaxWeight килограмм
+type WeightTier struct {
+	MaxWeight float64 `yaml:"max_weight"`
+	Price     float64 `yaml:"price"`
+	ExtraDays int     `yaml:"extra_days"`
+}
+
+// tariffStore - тарифы из файла, перечитываются при изменении файла
+type tariffStore struct {
+	path    string
+	mutex   sync.RWMutex
+	tariffs Tariffs
+	modTime time.Time
+}
+
+// load - перечитываем файл тарифов
+func (store *tariffStore) load() (errorMessage error) {
+	info, err := os.Stat(store.path)
+	if err != nil {
+		errorMessage = err
+		return
+	}
+
+	fileTariffs, err := ioutil.ReadFile(store.path)
+	if err != nil {
+		errorMessage = err
+		return
+	}
+
+	var loaded Tariffs
+	err = yaml.Unmarshal(fileTariffs, &loaded)
+	if err != nil {
+		errorMessage = err
+		return
+	}
+
+	store.mutex.Lock()
+	store.tariffs = loaded
+	store.modTime = info.ModTime()
+	store.mutex.Unlock()
+
+	return
+}
+
+// zone - достаем тариф зоны, перечитывая файл если он изменился после прошлой загрузки
+func (store *tariffStore) zone(code string) (zone Zone, found bool) {
+	info, err := os.Stat(store.path)
+	if err == nil {
+		store.mutex.RLock()
+		changed := !info.ModTime().Equal(store.modTime)
+		store.mutex.RUnlock()
+
+		if changed {
+			err = store.load()
+			if err != nil {
+				log.Println(err)
+			}
+		}
+	}
+
+	store.mutex.RLock()
+	zone, found = store.tariffs.Zones[code]
+	store.mutex.RUnlock()
+
+	return
+}
+
+// surcharge - надбавка и дополнительные дни для веса в килограммах
+func (zone *Zone) surcharge(weight float64) (price float64, extraDays int) {
+	for _, tier := range zone.WeightTiers {
+		if weight <= tier.MaxWeight {
+			return tier.Price, tier.ExtraDays
+		}
+	}
+
+	if len(zone.WeightTiers) > 0 {
+		last := zone.WeightTiers[len(zone.WeightTiers)-1]
+		price = last.Price + (weight-last.MaxWeight)*zone.PricePerKg
+		extraDays = last.ExtraDays
+		return
+	}
+
+	price = weight * zone.PricePerKg
+
+	return
+}
@@ -0,0 +1,98 @@
+package element
+
+import (
+	"testing"
+
+	"../catalog"
+)
+
+// listBenchmarks - страница 100 активных элементов с разными связями
+var listBenchmarks = []struct {
+	name   string
+	expand []string
+}{
+	{"plain", []string{}},
+	{"meta", []string{"meta"}},
+	{"properties", []string{"properties"}},
+	{"url", []string{"url"}},
+	{"meta+properties+section+url", []string{"meta", "properties", "section", "url"}},
+}
+
+func benchmarkRepository(b *testing.B) *repository {
+	conn := openFixture(b)
+	products, err := catalog.NewRepository(conn)
+	if err != nil {
+		b.Fatal(err)
+	}
+
+	return NewRepository(conn, products, "/upload/", fixtureSiteID).(*repository)
+}
+
+func benchmarkQuery(expand []string) Query {
+	return Query{
+		Filter: map[string]interface{}{"IBLOCK_ID": fixtureIblockID, "ACTIVE": "Y"},
+		Params: map[string]interface{}{"LIMIT": 100, "PAGE": 3, "ORDER": "SORT ASC"},
+		Expand: expand,
+	}
+}
+
+// BenchmarkList - страница списка элементов, связи грузятся одним запросом на пачку,
+// запуск: BITRIX_TEST_DSN='root:root@tcp(127.0.0.1:3306)/' BITRIX_BENCH_RECREATE=Y go test -run NONE -bench . ./element
+func BenchmarkList(b *testing.B) {
+	repo := benchmarkRepository(b)
+
+	for _, benchmark := range listBenchmarks {
+		query := benchmarkQuery(benchmark.expand)
+		b.Run(benchmark.name, func(b *testing.B) {
+			b.ReportAllocs()
+			for i := 0; i < b.N; i++ {
+				result, err := repo.List(query)
+				if err != nil {
+					b.Fatal(err)
+				}
+				if len(result.Items) != 100 {
+					b.Fatalf("got %d elements, want 100", len(result.Items))
+				}
+			}
+		})
+	}
+}
+
+// BenchmarkListPerElement - та же страница, но связи грузятся запросами на каждый элемент,
+// как до загрузки пачками: база для сравнения с BenchmarkList
+func BenchmarkListPerElement(b *testing.B) {
+	repo := benchmarkRepository(b)
+
+	for _, benchmark := range listBenchmarks {
+		query := benchmarkQuery(benchmark.expand)
+		selection, err := parseSelection(query)
+		if err != nil {
+			b.Fatal(err)
+		}
+		where, args, err := repo.prepareFilter(query.Filter)
+		if err != nil {
+			b.Fatal(err)
+		}
+
+		b.Run(benchmark.name, func(b *testing.B) {
+			b.ReportAllocs()
+			for i := 0; i < b.N; i++ {
+				var elements []*Element
+				err := repo.conn.Select(&elements, "SELECT "+selectColumns(selection)+
+					" FROM `b_iblock_element` t "+where+" ORDER BY t.SORT ASC, t.ID ASC LIMIT 100 OFFSET 200", args...)
+				if err != nil {
+					b.Fatal(err)
+				}
+				for _, element := range elements {
+					err = repo.enrich([]*Element{element}, selection)
+					if err != nil {
+						b.Fatal(err)
+					}
+				}
+				if len(elements) != 100 {
+					b.Fatalf("got %d elements, want 100", len(elements))
+				}
+			}
+		})
+	}
+}
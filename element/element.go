@@ -1,134 +1,114 @@
 package element
 
 import (
-	"database/sql"
 	"encoding/json"
-	"io/ioutil"
-	"log"
 	"net/http"
 	"strconv"
 	"strings"
 
-	"github.com/jmoiron/sqlx"
-	yaml "gopkg.in/yaml.v2"
+	"../catalog"
+	"../internal/database"
+	"../internal/filter"
+	"../internal/iblock"
+	"../internal/ndjson"
 )
 
 // Element - структура элемента
 type Element struct {
-	ID                uint64            `db:"ID" json:"id"`
-	Code              nullString        `db:"CODE" json:"code"`
-	Name              string            `db:"NAME" json:"name"`
-	PreviewPicture    nullString        `db:"PREVIEW_PICTURE" json:"preview_picture"`
-	DetailPicture     nullString        `db:"DETAIL_PICTURE" json:"detail_picture"`
-	PreviewText       nullString        `db:"PREVIEW_TEXT" json:"preview_text"`
-	DetailText        nullString        `db:"DETAIL_TEXT" json:"detail_text"`
-	XMLID             nullString        `db:"XML_ID" json:"xml_id"`
-	IblockID          uint64            `db:"IBLOCK_ID" json:"iblock_id"`
-	IblockSectionID   nullInt64         `db:"IBLOCK_SECTION_ID" json:"iblock_section_id"`
-	Active            bitrixBool        `db:"ACTIVE" json:"active"`
-	ActiveFrom        nullString        `db:"ACTIVE_FROM" json:"active_from"`
-	ActiveTo          nullString        `db:"ACTIVE_TO" json:"active_to"`
-	Sort              uint64            `db:"SORT" json:"sort"`
-	SearchableContent nullString        `db:"SEARCHABLE_CONTENT" json:"searchable_content"`
-	DateCreate        nullString        `db:"DATE_CREATE" json:"date_create"`
-	CreatedBy         uint64            `db:"CREATED_BY" json:"created_by"`
-	TimestampX        nullString        `db:"TIMESTAMP_X" json:"timestamp_x"`
-	ModifiedBy        nullInt64         `db:"MODIFIED_BY" json:"modified_by"`
-	ShowCounter       nullInt64         `db:"SHOW_COUNTER" json:"show_counter"`
-	Meta              map[string]string `json:"meta"`
-}
-
-// Properties - структура свойств
-type Properties struct {
-	Name  nullString `db:"Name" json:"name"`
-	Code  nullString `db:"Code" json:"code"`
-	Value nullString `db:"Value" json:"value"`
-}
-
-type nullInt64 struct {
-	sql.NullInt64
-}
-
-type nullFloat64 struct {
-	sql.NullFloat64
-}
-
-type nullString struct {
-	sql.NullString
-}
-
-type bitrixBool struct {
-	sql.NullString
-}
-
-// EnvStruct - структура для данных из env.yml файла
-type EnvStruct struct {
-	DBName     string `yaml:"db_name"`
-	DBLogin    string `yaml:"db_login"`
-	DBPassword string `yaml:"db_password"`
-	DBHost     string `yaml:"db_host"`
-	DBPort     int    `yaml:"db_port"`
-}
-
-var env EnvStruct
-var fields []string
-var mysqlConnectString string
-
-func init() {
-	fields = []string{
-		"ID",
-		"CODE",
-		"XML_ID ",
-		"NAME",
-		"IBLOCK_ID",
-		"IBLOCK_SECTION_ID",
-		"ACTIVE",
-		"ACTIVE_FROM",
-		"ACTIVE_TO",
-		"SORT",
-		"PREVIEW_PICTURE",
-		"PREVIEW_TEXT",
-		"DETAIL_PICTURE",
-		"DETAIL_TEXT",
-		"SEARCHABLE_CONTENT",
-		"DATE_CREATE",
-		"CREATED_BY",
-		"TIMESTAMP_X",
-		"MODIFIED_BY",
-		"SHOW_COUNTER",
-	}
-	fileEnv, err := ioutil.ReadFile("env.yml")
-	if err != nil {
-		log.Fatal(err)
-	}
-
-	err = yaml.Unmarshal(fileEnv, &env)
-	if err != nil {
-		log.Fatal(err)
-	}
-
-	mysqlConnectString = env.DBLogin + ":" + env.DBPassword +
-		"@tcp(" + env.DBHost + ":" + strconv.Itoa(env.DBPort) + ")/" + env.DBName
-
+	ID                uint64               `db:"ID" json:"id"`
+	Code              database.NullString  `db:"CODE" json:"code"`
+	Name              string               `db:"NAME" json:"name"`
+	PreviewPicture    iblock.Picture       `db:"PREVIEW_PICTURE" json:"preview_picture"`
+	DetailPicture     iblock.Picture       `db:"DETAIL_PICTURE" json:"detail_picture"`
+	PreviewText       database.NullString  `db:"PREVIEW_TEXT" json:"preview_text"`
+	DetailText        database.NullString  `db:"DETAIL_TEXT" json:"detail_text"`
+	XMLID             database.NullString  `db:"XML_ID" json:"xml_id"`
+	IblockID          uint64               `db:"IBLOCK_ID" json:"iblock_id"`
+	IblockSectionID   database.NullInt64   `db:"IBLOCK_SECTION_ID" json:"iblock_section_id"`
+	Active            database.Bool        `db:"ACTIVE" json:"active"`
+	ActiveFrom        database.NullString  `db:"ACTIVE_FROM" json:"active_from"`
+	ActiveTo          database.NullString  `db:"ACTIVE_TO" json:"active_to"`
+	Sort              uint64               `db:"SORT" json:"sort"`
+	SearchableContent database.NullString  `db:"SEARCHABLE_CONTENT" json:"searchable_content"`
+	DateCreate        database.NullString  `db:"DATE_CREATE" json:"date_create"`
+	CreatedBy         uint64               `db:"CREATED_BY" json:"created_by"`
+	TimestampX        database.NullString  `db:"TIMESTAMP_X" json:"timestamp_x"`
+	ModifiedBy        database.NullInt64   `db:"MODIFIED_BY" json:"modified_by"`
+	ShowCounter       database.NullInt64   `db:"SHOW_COUNTER" json:"show_counter"`
+	Meta              map[string]string    `json:"meta"`
+	Props             map[string]*Property `json:"properties"`
+	Section           *Section             `json:"section"`
+	Catalog           *catalog.Catalog     `json:"catalog"`
+	Prices            []catalog.Price      `json:"prices"`
+	DetailPageURL     string               `json:"detail_page_url"`
+	ListPageURL       string               `json:"list_page_url"`
+	Cursor            filter.CursorValues  `db:"CURSOR_VALUES" json:"-"`
+	selection         filter.Selection
+}
+
+// MarshalJSON - в ответ попадают только выбранные поля и подгруженные связи
+func (element Element) MarshalJSON() ([]byte, error) {
+	type plain Element
+	return element.selection.Marshal((*plain)(&element))
+}
+
+// Section - основной раздел элемента
+type Section struct {
+	ID              uint64              `db:"ID" json:"id"`
+	Code            database.NullString `db:"CODE" json:"code"`
+	XMLID           database.NullString `db:"XML_ID" json:"xml_id"`
+	Name            string              `db:"NAME" json:"name"`
+	IblockSectionID database.NullInt64  `db:"IBLOCK_SECTION_ID" json:"iblock_section_id"`
+	DepthLevel      uint64              `db:"DEPTH_LEVEL" json:"depth_level"`
+}
+
+// Query - тело запроса списка элементов
+type Query struct {
+	Filter map[string]interface{} `json:"filter"`
+	Params map[string]interface{} `json:"params"`
+	// Select - колонки элемента, пусто - все
+	Select []string `json:"select"`
+	// Expand - связи (meta, properties, section, catalog, prices, url), не передан - meta и url
+	Expand []string `json:"expand"`
+}
+
+// ListResult - ответ списка элементов со страницей
+type ListResult struct {
+	Items []*Element `json:"items"`
+	filter.Pagination
+}
+
+// Handler - обработчики запросов к элементам
+type Handler struct {
+	repository Repository
+}
+
+// NewHandler - создаем обработчики с переданным хранилищем
+func NewHandler(repository Repository) *Handler {
+	return &Handler{repository: repository}
 }
 
 // InfoByID - получение одной записи по ID
-func InfoByID(response http.ResponseWriter, request *http.Request) {
+func (handler *Handler) InfoByID(response http.ResponseWriter, request *http.Request) {
 	requestURL := strings.Split(request.RequestURI, "/")
 	elementID := requestURL[2]
 
-	filter := map[string]map[string]string{
-		"filter": map[string]string{
-			"ID": elementID,
-		},
-	}
+	query := Query{Filter: map[string]interface{}{
+		"ID": elementID,
+	}}
+	query.Select, query.Expand = filter.QuerySelection(request.URL.Query())
 
-	elements, err := getData(filter)
+	elements, err := handler.repository.Find(query)
 	if err != nil {
 		response.WriteHeader(http.StatusBadRequest)
 		response.Write([]byte(err.Error()))
 		return
 	}
+	if len(elements) == 0 {
+		response.WriteHeader(http.StatusNotFound)
+		response.Write([]byte("element not found"))
+		return
+	}
 
 	result, _ := json.Marshal(elements[0])
 	response.Header().Set("Content-Type", "application/json")
@@ -137,22 +117,26 @@ func InfoByID(response http.ResponseWriter, request *http.Request) {
 }
 
 // InfoByCode - получение одной записи по Code
-func InfoByCode(response http.ResponseWriter, request *http.Request) {
+func (handler *Handler) InfoByCode(response http.ResponseWriter, request *http.Request) {
 	requestURL := strings.Split(request.RequestURI, "/")
 	elementCode := requestURL[2]
 
-	filter := map[string]map[string]string{
-		"filter": map[string]string{
-			"CODE": elementCode,
-		},
-	}
+	query := Query{Filter: map[string]interface{}{
+		"CODE": elementCode,
+	}}
+	query.Select, query.Expand = filter.QuerySelection(request.URL.Query())
 
-	elements, err := getData(filter)
+	elements, err := handler.repository.Find(query)
 	if err != nil {
 		response.WriteHeader(http.StatusBadRequest)
 		response.Write([]byte(err.Error()))
 		return
 	}
+	if len(elements) == 0 {
+		response.WriteHeader(http.StatusNotFound)
+		response.Write([]byte("element not found"))
+		return
+	}
 
 	result, _ := json.Marshal(elements[0])
 	response.Header().Set("Content-Type", "application/json")
@@ -161,20 +145,30 @@ func InfoByCode(response http.ResponseWriter, request *http.Request) {
 }
 
 // List - достаем елементы по фильтру
-func List(response http.ResponseWriter, request *http.Request) {
-	// var context []interface{}
-	var filter map[string]map[string]string
+func (handler *Handler) List(response http.ResponseWriter, request *http.Request) {
+	var query Query
 
-	body, _ := ioutil.ReadAll(request.Body)
 	defer request.Body.Close()
-	err := json.Unmarshal(body, &filter)
+	decoder := json.NewDecoder(request.Body)
+	decoder.UseNumber()
+	err := decoder.Decode(&query)
 
 	if err != nil {
 		response.WriteHeader(http.StatusBadRequest)
 		response.Write([]byte(err.Error()))
+		return
+	}
+
+	if ndjson.Accepts(request) {
+		writer := ndjson.NewWriter(response)
+		err = handler.repository.Stream(request.Context(), query, func(element *Element) error {
+			return writer.Write(element)
+		})
+		writer.Close(err)
+		return
 	}
 
-	elements, err := getData(filter)
+	elements, err := handler.repository.List(query)
 	if err != nil {
 		response.WriteHeader(http.StatusBadRequest)
 		response.Write([]byte(err.Error()))
@@ -187,247 +181,48 @@ func List(response http.ResponseWriter, request *http.Request) {
 	response.Write(result)
 }
 
-// GetProperties - получение свойств елемента
-func GetProperties(response http.ResponseWriter, request *http.Request) {
+// GetProperties - свойства элемента с типами, ключ - символьный код свойства
+func (handler *Handler) GetProperties(response http.ResponseWriter, request *http.Request) {
 	requestURL := strings.Split(request.RequestURI, "/")
-	elementID, _ := strconv.ParseUint(requestURL[2], 10, 32)
+	elementID, _ := strconv.ParseUint(requestURL[2], 10, 64)
 
-	props, err := getProperties(uint(elementID))
+	props, err := handler.repository.Properties(elementID)
 	if err != nil {
 		response.WriteHeader(http.StatusBadRequest)
 		response.Write([]byte(err.Error()))
 		return
 	}
+	if props == nil {
+		response.WriteHeader(http.StatusNotFound)
+		response.Write([]byte("element not found"))
+		return
+	}
 
 	result, _ := json.Marshal(props)
 	response.Header().Set("Content-Type", "application/json")
 	response.WriteHeader(http.StatusOK)
 	response.Write(result)
-
 }
 
-func getData(filter map[string]map[string]string) (elements []*Element, errorMessage error) {
-	var where, param string
-	var args []interface{}
-
-	conn, err := sqlx.Connect("mysql", mysqlConnectString)
-	defer conn.Close()
-
-	if err != nil {
-		errorMessage = err
-	}
-
-	selectedFields := prepareSelect()
-	table := " FROM `b_iblock_element` t "
-	if filter, found := filter["filter"]; found {
-		where, args = prepareFilter(filter)
-	}
-	if params, found := filter["params"]; found {
-		param = prepareParams(params)
-	}
-
-	query := "SELECT " + selectedFields +
-		table +
-		where +
-		param
+// Path - цепочка разделов до основного раздела элемента (хлебные крошки) и DETAIL_PAGE_URL элемента
+func (handler *Handler) Path(response http.ResponseWriter, request *http.Request) {
+	requestURL := strings.Split(request.RequestURI, "/")
+	elementID, _ := strconv.ParseUint(requestURL[2], 10, 64)
 
-	err = conn.Select(&elements, query, args...)
+	path, err := handler.repository.Path(elementID)
 	if err != nil {
-		errorMessage = err
+		response.WriteHeader(http.StatusBadRequest)
+		response.Write([]byte(err.Error()))
 		return
 	}
-
-	for _, element := range elements {
-		element.Meta, err = getMeta(conn, element.ID)
-		if err != nil {
-			errorMessage = err
-			break
-		}
-	}
-
-	return
-}
-
-func getMeta(conn *sqlx.DB, elementID uint64) (meta map[string]string, errorMessage error) {
-	query := "SELECT (SELECT CODE FROM b_iblock_iproperty ip WHERE ip.ID = ei.IPROP_ID) AS meta_name," +
-		" ei.VALUE AS value" +
-		" FROM b_iblock_iblock_iprop ei" +
-		" WHERE ei.IBLOCK_ID = ?"
-
-	rows, err := conn.Queryx(query, elementID)
-	meta = make(map[string]string, 0)
-	for rows.Next() {
-		var metaName string
-		var metaValue string
-		err = rows.Scan(&metaName, &metaValue)
-		meta[metaName] = metaValue
-		if err != nil {
-			errorMessage = err
-		}
-	}
-	return
-}
-
-func prepareSelect() (query string) {
-	var fieldsArray []string
-	for _, field := range fields {
-		f := strings.ToUpper(field)
-		fieldsArray = append(fieldsArray, "`t`."+f)
-	}
-	query = strings.Join(fieldsArray, ", ")
-
-	return
-}
-
-func prepareFilter(filter map[string]string) (where string, values []interface{}) {
-	var whereTemp []string
-
-	if id, ok := filter["ID"]; ok {
-		whereTemp = append(whereTemp, "t.ID = ?")
-		values = append(values, id)
-	}
-	if code, ok := filter["CODE"]; ok {
-		whereTemp = append(whereTemp, "t.CODE = ?")
-		values = append(values, code)
-	}
-	if active, ok := filter["ACTIVE"]; ok {
-		whereTemp = append(whereTemp, "t.ACTIVE = ?")
-		values = append(values, active)
-	}
-	if activeFrom, ok := filter[">ACTIVE_FROM"]; ok {
-		whereTemp = append(whereTemp, "t.ACTIVE_FROM > ?")
-		values = append(values, activeFrom)
-	}
-	if activeFrom, ok := filter["<ACTIVE_FROM"]; ok {
-		whereTemp = append(whereTemp, "t.ACTIVE_FROM < ?")
-		values = append(values, activeFrom)
-	}
-	if activeTo, ok := filter[">ACTIVE_TO"]; ok {
-		whereTemp = append(whereTemp, "t.ACTIVE_TO > ?")
-		values = append(values, activeTo)
-	}
-	if activeTo, ok := filter["<ACTIVE_TO"]; ok {
-		whereTemp = append(whereTemp, "t.ACTIVE_TO < ?")
-		values = append(values, activeTo)
-	}
-	if name, ok := filter["NAME"]; ok {
-		whereTemp = append(whereTemp, "t.NAME = ?")
-		values = append(values, name)
-	}
-	if name, ok := filter["NAME%"]; ok {
-		whereTemp = append(whereTemp, "t.NAME LIKE ?%")
-		values = append(values, name)
-	}
-	if name, ok := filter["%NAME"]; ok {
-		whereTemp = append(whereTemp, "t.NAME LIKE %?")
-		values = append(values, name)
-	}
-	if name, ok := filter["%NAME%"]; ok {
-		whereTemp = append(whereTemp, "t.NAME LIKE %?%")
-		values = append(values, name)
-	}
-	if xmlID, ok := filter["XML_ID"]; ok {
-		whereTemp = append(whereTemp, "t.XML_ID = ?")
-		values = append(values, xmlID)
-	}
-	if iblockID, ok := filter["IBLOCK_ID"]; ok {
-		whereTemp = append(whereTemp, "t.IBLOCK_ID = ?")
-		values = append(values, iblockID)
-	}
-	if iblockSectionID, ok := filter["IBLOCK_SECTION_ID"]; ok {
-		whereTemp = append(whereTemp, "t.IBLOCK_SECTION_ID = ?")
-		values = append(values, iblockSectionID)
-	}
-
-	where = " WHERE " + strings.Join(whereTemp, " AND ")
-
-	return
-}
-
-func prepareParams(filter map[string]string) (params string) {
-	if limit, ok := filter["LIMIT"]; ok {
-		params += " LIMIT " + limit
-	} else {
-		params += " LIMIT 100"
-	}
-	if order, ok := filter["ORDER"]; ok {
-		params += " ORDER BY " + order
-	} else {
-		params += " ORDER BY SORT ASC"
-	}
-	if group, ok := filter["GROUP"]; ok {
-		params += " GROUP BY " + group
-	}
-
-	return
-}
-
-// getProperties - получение свойств элемента
-func getProperties(elementID uint) (props []Properties, errorMessage error) {
-
-	conn, err := sqlx.Connect("mysql", mysqlConnectString)
-	defer conn.Close()
-
-	query := "SELECT " +
-		"`prop`.NAME AS Name, " +
-		"`prop`.CODE AS Code, " +
-		"IF (`prop`.PROPERTY_TYPE = 'F', IFNULL (CONCAT(`file`.SUBDIR, '/', `file`.FILE_NAME), ''), IFNULL(`prop_enum`.VALUE, `el_prop`.VALUE)) AS Value  " +
-		"FROM `b_iblock_element` el " +
-		"LEFT JOIN `b_iblock_element_property` el_prop ON `el_prop`.IBLOCK_ELEMENT_ID = `el`.ID " +
-		"LEFT JOIN `b_iblock_property` prop ON `prop`.ID = `el_prop`.IBLOCK_PROPERTY_ID " +
-		"LEFT JOIN `b_iblock_property_enum` prop_enum ON `prop_enum`.ID = `el_prop`.VALUE " +
-		"LEFT JOIN `b_file` file ON `file`.ID = `el_prop`.VALUE " +
-		"WHERE `el`.ID = ?"
-
-	if err != nil {
-		errorMessage = err
-	}
-
-	err = conn.Select(&props, query, elementID)
-	if err != nil {
-		errorMessage = err
+	if path == nil {
+		response.WriteHeader(http.StatusNotFound)
+		response.Write([]byte("element not found"))
 		return
 	}
 
-	return
-}
-
-// MarshalJSON MarshalJSON interface redefinition
-func (r nullInt64) MarshalJSON() ([]byte, error) {
-	if r.Valid {
-		return json.Marshal(r.Int64)
-	}
-
-	return json.Marshal(0)
-
-}
-
-// MarshalJSON MarshalJSON interface redefinition
-func (r nullFloat64) MarshalJSON() ([]byte, error) {
-	if r.Valid {
-		return json.Marshal(r.Float64)
-	}
-
-	return json.Marshal(0)
-
-}
-
-// MarshalJSON MarshalJSON interface redefinition
-func (r nullString) MarshalJSON() ([]byte, error) {
-	if r.Valid {
-		return json.Marshal(r.String)
-	}
-
-	return json.Marshal("")
-
-}
-
-// MarshalJSON MarshalJSON interface redefinition
-func (r bitrixBool) MarshalJSON() ([]byte, error) {
-	if r.String == "N" {
-		return json.Marshal(false)
-	}
-
-	return json.Marshal(true)
-
+	result, _ := json.Marshal(path)
+	response.Header().Set("Content-Type", "application/json")
+	response.WriteHeader(http.StatusOK)
+	response.Write(result)
 }
@@ -0,0 +1,247 @@
+package element
+
+import (
+	"github.com/jmoiron/sqlx"
+
+	"../catalog"
+	"../internal/filter"
+	"../internal/iblock"
+)
+
+// expansions - связи, которые можно подгрузить к элементу (expand)
+var expansions = []string{"meta", "properties", "section", "catalog", "prices", "url"}
+
+// defaultExpand - связи, если expand не передан
+var defaultExpand = []string{"meta", "url"}
+
+// parseSelection - select и expand запроса
+func parseSelection(query Query) (filter.Selection, error) {
+	return filter.ParseSelection(query.Select, query.Expand, fields, expansions, defaultExpand)
+}
+
+// selectColumns - колонки выборки, поля нужные для связей берем всегда
+func selectColumns(selection filter.Selection) string {
+	var required []string
+	if selection.Expands("properties") {
+		required = append(required, "IBLOCK_ID")
+	}
+	if selection.Expands("section") {
+		required = append(required, "IBLOCK_SECTION_ID")
+	}
+	if selection.Expands("url") {
+		required = append(required, "CODE", "XML_ID", "IBLOCK_ID", "IBLOCK_SECTION_ID")
+	}
+
+	return selection.Columns(fields, required...)
+}
+
+// enrich - подгружаем запрошенные связи для пачки элементов, по запросу на связь
+func (repo *repository) enrich(elements []*Element, selection filter.Selection) (errorMessage error) {
+	for _, element := range elements {
+		element.selection = selection
+	}
+	if len(elements) == 0 {
+		return
+	}
+
+	errorMessage = repo.loadPictures(elements, selection)
+	if errorMessage != nil {
+		return
+	}
+	if selection.Expands("meta") {
+		errorMessage = repo.loadMeta(elements)
+		if errorMessage != nil {
+			return
+		}
+	}
+	if selection.Expands("properties") {
+		errorMessage = repo.loadProperties(elements)
+		if errorMessage != nil {
+			return
+		}
+	}
+	if selection.Expands("section") {
+		errorMessage = repo.loadSections(elements)
+		if errorMessage != nil {
+			return
+		}
+	}
+	if selection.Expands("catalog") {
+		errorMessage = repo.loadCatalog(elements)
+		if errorMessage != nil {
+			return
+		}
+	}
+	if selection.Expands("prices") {
+		errorMessage = repo.loadPrices(elements)
+		if errorMessage != nil {
+			return
+		}
+	}
+	if selection.Expands("url") {
+		errorMessage = repo.loadURLs(elements)
+	}
+
+	return
+}
+
+// loadPictures - файлы картинок анонса и детальной для пачки элементов, если колонки выбраны
+func (repo *repository) loadPictures(elements []*Element, selection filter.Selection) error {
+	var pictures []*iblock.Picture
+	for _, element := range elements {
+		if selection.Selected("PREVIEW_PICTURE") {
+			pictures = append(pictures, &element.PreviewPicture)
+		}
+		if selection.Selected("DETAIL_PICTURE") {
+			pictures = append(pictures, &element.DetailPicture)
+		}
+	}
+
+	return iblock.ResolvePictures(repo.conn, repo.uploadURL, pictures)
+}
+
+// loadMeta - мета (SEO) для пачки элементов одним запросом
+func (repo *repository) loadMeta(elements []*Element) (errorMessage error) {
+	byID := make(map[uint64]*Element, len(elements))
+	ids := make([]uint64, 0, len(elements))
+	for _, element := range elements {
+		element.Meta = make(map[string]string)
+		byID[element.ID] = element
+		ids = append(ids, element.ID)
+	}
+
+	query, args, err := sqlx.In("SELECT ei.ELEMENT_ID, ip.CODE, ei.VALUE"+
+		" FROM b_iblock_element_iprop ei"+
+		" INNER JOIN b_iblock_iproperty ip ON ip.ID = ei.IPROP_ID"+
+		" WHERE ei.ELEMENT_ID IN (?)", ids)
+	if err != nil {
+		errorMessage = err
+		return
+	}
+
+	rows, err := repo.conn.Queryx(query, args...)
+	if err != nil {
+		errorMessage = err
+		return
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var elementID uint64
+		var metaName, metaValue string
+		err = rows.Scan(&elementID, &metaName, &metaValue)
+		if err != nil {
+			errorMessage = err
+			return
+		}
+		if element, found := byID[elementID]; found {
+			element.Meta[metaName] = metaValue
+		}
+	}
+	errorMessage = rows.Err()
+
+	return
+}
+
+// loadSections - основные разделы для пачки элементов
+func (repo *repository) loadSections(elements []*Element) (errorMessage error) {
+	var ids []int64
+	for _, element := range elements {
+		if element.IblockSectionID.Valid {
+			ids = append(ids, element.IblockSectionID.Int64)
+		}
+	}
+	if len(ids) == 0 {
+		return
+	}
+
+	query, args, err := sqlx.In("SELECT ID, CODE, XML_ID, NAME, IBLOCK_SECTION_ID, DEPTH_LEVEL"+
+		" FROM b_iblock_section"+
+		" WHERE ID IN (?)", ids)
+	if err != nil {
+		errorMessage = err
+		return
+	}
+
+	var sections []*Section
+	errorMessage = repo.conn.Select(&sections, query, args...)
+	if errorMessage != nil {
+		return
+	}
+
+	byID := make(map[int64]*Section, len(sections))
+	for _, section := range sections {
+		byID[int64(section.ID)] = section
+	}
+	for _, element := range elements {
+		if element.IblockSectionID.Valid {
+			element.Section = byID[element.IblockSectionID.Int64]
+		}
+	}
+
+	return
+}
+
+// loadCatalog - данные каталога для пачки элементов, у элементов не из каталога пусто
+func (repo *repository) loadCatalog(elements []*Element) (errorMessage error) {
+	products, errorMessage := repo.products.Products(productIDs(elements), nil)
+	if errorMessage != nil {
+		return
+	}
+
+	for _, element := range elements {
+		element.Catalog = products[uint32(element.ID)]
+	}
+
+	return
+}
+
+// loadPrices - цены для пачки элементов
+func (repo *repository) loadPrices(elements []*Element) (errorMessage error) {
+	prices, errorMessage := repo.products.Prices(productIDs(elements), nil)
+	if errorMessage != nil {
+		return
+	}
+
+	for _, element := range elements {
+		element.Prices = prices[uint32(element.ID)]
+		if element.Prices == nil {
+			element.Prices = []catalog.Price{}
+		}
+	}
+
+	return
+}
+
+// loadURLs - DETAIL_PAGE_URL и LIST_PAGE_URL по шаблонам инфоблоков для пачки элементов
+func (repo *repository) loadURLs(elements []*Element) (errorMessage error) {
+	fields := make([]iblock.URLElement, 0, len(elements))
+	for _, element := range elements {
+		fields = append(fields, iblock.URLElement{
+			ID:        element.ID,
+			IblockID:  element.IblockID,
+			SectionID: uint64(element.IblockSectionID.Int64),
+			Code:      element.Code.String,
+			XMLID:     element.XMLID.String,
+		})
+	}
+
+	urls, errorMessage := iblock.ElementURLs(repo.conn, repo.siteID, fields)
+	if errorMessage != nil {
+		return
+	}
+	for _, element := range elements {
+		element.DetailPageURL = urls[element.ID].Page
+		element.ListPageURL = urls[element.ID].List
+	}
+
+	return
+}
+
+func productIDs(elements []*Element) (ids []uint32) {
+	for _, element := range elements {
+		ids = append(ids, uint32(element.ID))
+	}
+
+	return
+}
@@ -0,0 +1,61 @@
+package element
+
+import (
+	"database/sql"
+
+	"../internal/database"
+	"../internal/iblock"
+)
+
+// Path - цепочка разделов от верхнего уровня до основного раздела элемента и ссылка на элемент
+type Path struct {
+	Items         []iblock.PathSection `json:"items"`
+	DetailPageURL string               `json:"detail_page_url"`
+}
+
+// Path - хлебные крошки элемента по основному разделу со ссылками по шаблонам инфоблока, nil - элемента нет
+func (repo *repository) Path(elementID uint64) (path *Path, errorMessage error) {
+	var element struct {
+		ID              uint64              `db:"ID"`
+		Code            database.NullString `db:"CODE"`
+		XMLID           database.NullString `db:"XML_ID"`
+		IblockID        uint64              `db:"IBLOCK_ID"`
+		IblockSectionID database.NullInt64  `db:"IBLOCK_SECTION_ID"`
+	}
+	err := repo.conn.Get(&element, "SELECT ID, CODE, XML_ID, IBLOCK_ID, IBLOCK_SECTION_ID FROM b_iblock_element WHERE ID = ?", elementID)
+	if err == sql.ErrNoRows {
+		return
+	}
+	if err != nil {
+		errorMessage = err
+		return
+	}
+
+	path = &Path{Items: []iblock.PathSection{}}
+	if element.IblockSectionID.Valid {
+		sectionID := uint64(element.IblockSectionID.Int64)
+		paths, err := iblock.SectionPaths(repo.conn, []uint64{sectionID})
+		if err != nil {
+			errorMessage = err
+			return
+		}
+		if items, found := paths[sectionID]; found {
+			path.Items = items
+		}
+	}
+
+	iblocks, errorMessage := iblock.FindIblocks(repo.conn, repo.siteID, []uint64{element.IblockID})
+	if errorMessage != nil {
+		return
+	}
+	if info, found := iblocks[element.IblockID]; found {
+		info.SetURLs(path.Items)
+		path.DetailPageURL = info.DetailURL(iblock.URLElement{
+			ID:    element.ID,
+			Code:  element.Code.String,
+			XMLID: element.XMLID.String,
+		}, path.Items)
+	}
+
+	return
+}
@@ -0,0 +1,139 @@
+package element
+
+import (
+	"../internal/iblock"
+)
+
+// Property - значение свойства элемента с типом. Value для MULTIPLE=Y - массив:
+// N - число, L - вариант списка {id, value, xml_id}, F - файл, E и G - ID элемента и раздела, остальное строкой
+type Property struct {
+	ID          uint64      `json:"id"`
+	Code        string      `json:"code"`
+	Name        string      `json:"name"`
+	Type        string      `json:"type"`
+	UserType    string      `json:"user_type"`
+	Multiple    bool        `json:"multiple"`
+	Value       interface{} `json:"value"`
+	Description interface{} `json:"description"`
+}
+
+func newProperty(property *iblock.Property) *Property {
+	result := &Property{
+		ID:          property.ID,
+		Code:        property.Code.String,
+		Name:        property.Name,
+		Type:        property.Type,
+		UserType:    property.UserType.String,
+		Multiple:    property.Multiple.IsTrue(),
+		Description: "",
+	}
+	if result.Multiple {
+		result.Value = []interface{}{}
+		result.Description = []string{}
+	}
+
+	return result
+}
+
+// add - значение в свойство, у множественных дописывается в массив
+func (property *Property) add(value interface{}, description string) {
+	if !property.Multiple {
+		property.Value = value
+		property.Description = description
+		return
+	}
+
+	property.Value = append(property.Value.([]interface{}), value)
+	property.Description = append(property.Description.([]string), description)
+}
+
+// loadProperties - все свойства инфоблока с типами и значениями для пачки элементов
+func (repo *repository) loadProperties(elements []*Element) (errorMessage error) {
+	byID := make(map[uint64]*Element, len(elements))
+	var iblockIDs []uint64
+	seen := make(map[uint64]bool)
+	for _, element := range elements {
+		element.Props = make(map[string]*Property)
+		byID[element.ID] = element
+		if !seen[element.IblockID] {
+			seen[element.IblockID] = true
+			iblockIDs = append(iblockIDs, element.IblockID)
+		}
+	}
+
+	properties, errorMessage := iblock.IblockProperties(repo.conn, iblockIDs)
+	if errorMessage != nil {
+		return
+	}
+	byIblock := make(map[uint64][]*iblock.Property)
+	propertyByID := make(map[uint64]*iblock.Property, len(properties))
+	for index := range properties {
+		property := &properties[index]
+		byIblock[property.IblockID] = append(byIblock[property.IblockID], property)
+		propertyByID[property.ID] = property
+	}
+
+	elementIDs := make(map[uint64][]uint64)
+	for _, element := range elements {
+		elementIDs[element.IblockID] = append(elementIDs[element.IblockID], element.ID)
+	}
+	values, errorMessage := iblock.ElementValues(repo.conn, byIblock, elementIDs)
+	if errorMessage != nil {
+		return
+	}
+
+	var enumIDs, fileIDs []uint64
+	for _, value := range values {
+		property, found := propertyByID[value.PropertyID]
+		if !found {
+			continue
+		}
+		switch property.Type {
+		case "L":
+			enumIDs = append(enumIDs, value.EnumID())
+		case "F":
+			fileIDs = append(fileIDs, value.Uint())
+		}
+	}
+	enums, errorMessage := iblock.FindEnums(repo.conn, enumIDs)
+	if errorMessage != nil {
+		return
+	}
+	files, errorMessage := iblock.FindFiles(repo.conn, repo.uploadURL, fileIDs)
+	if errorMessage != nil {
+		return
+	}
+
+	for _, element := range elements {
+		for _, property := range byIblock[element.IblockID] {
+			element.Props[property.Key()] = newProperty(property)
+		}
+	}
+	for _, value := range values {
+		element, found := byID[value.ElementID]
+		if !found {
+			continue
+		}
+		property, found := propertyByID[value.PropertyID]
+		if !found || property.IblockID != element.IblockID {
+			continue
+		}
+
+		var typed interface{}
+		switch property.Type {
+		case "N":
+			typed = value.Number()
+		case "L":
+			typed = enums[value.EnumID()]
+		case "F":
+			typed = files[value.Uint()]
+		case "E", "G":
+			typed = value.Uint()
+		default:
+			typed = value.Value.String
+		}
+		element.Props[property.Key()].add(typed, value.Description.String)
+	}
+
+	return
+}
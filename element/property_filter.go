@@ -0,0 +1,94 @@
+package element
+
+import (
+	"errors"
+	"strconv"
+	"strings"
+
+	"../internal/filter"
+	"../internal/iblock"
+)
+
+const propertyPrefix = "PROPERTY_"
+
+// propertyFilter - условие по свойству PROPERTY_<CODE|ID> через подзапрос к таблице значений,
+// EXISTS вместо JOIN чтобы множественные значения не размножали строки элементов
+func (repo *repository) propertyFilter(condition filter.Condition, iblockID uint64) (where string, args []interface{}, ok bool, errorMessage error) {
+	if !strings.HasPrefix(condition.Field, propertyPrefix) {
+		return
+	}
+	ok = true
+	code := strings.TrimPrefix(condition.Field, propertyPrefix)
+
+	properties, err := iblock.FindProperties(repo.conn, code, iblockID)
+	if err != nil {
+		errorMessage = err
+		return
+	}
+	if len(properties) == 0 {
+		errorMessage = errors.New("unknown property " + code)
+		return
+	}
+
+	var conditions []string
+	for index := range properties {
+		exists, existsArgs, err := propertyCondition(&properties[index], condition)
+		if err != nil {
+			errorMessage = err
+			return
+		}
+		conditions = append(conditions, exists)
+		args = append(args, existsArgs...)
+	}
+
+	where = "(" + strings.Join(conditions, " OR ") + ")"
+	// null - у элемента нет значения свойства
+	if condition.Negate != condition.Null {
+		where = "NOT " + where
+	}
+
+	return
+}
+
+func propertyCondition(property *iblock.Property, condition filter.Condition) (where string, args []interface{}, errorMessage error) {
+	var from, column string
+
+	if property.InSingleTable() {
+		from = " FROM " + property.SingleTable() + " fp"
+		column = "fp." + property.Column()
+	} else {
+		from = " FROM " + property.ValueTable() + " fp"
+		column = "fp.VALUE"
+		if property.Type == "N" {
+			column = "fp.VALUE_NUM"
+		}
+		if property.Type == "L" {
+			column = "fp.VALUE_ENUM"
+		}
+	}
+
+	// списки фильтруются по XML_ID варианта значения
+	if property.Type == "L" {
+		from += " INNER JOIN b_iblock_property_enum fe ON fe.ID = " + column
+		column = "fe.XML_ID"
+	}
+
+	where = "EXISTS (SELECT 1" + from + " WHERE fp.IBLOCK_ELEMENT_ID = t.ID"
+	if !property.InSingleTable() {
+		where += " AND fp.IBLOCK_PROPERTY_ID = " + strconv.FormatUint(property.ID, 10)
+	}
+
+	if condition.Null {
+		// в таблице одиночных значений строка есть всегда, пустое значение - NULL в колонке
+		if property.InSingleTable() {
+			where += " AND " + column + " IS NOT NULL"
+		}
+		where += ")"
+		return
+	}
+
+	value, args, errorMessage := condition.SQL(column)
+	where += " AND " + value + ")"
+
+	return
+}
@@ -0,0 +1,244 @@
+package element
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/jmoiron/sqlx"
+
+	"../catalog"
+	"../internal/filter"
+)
+
+// Repository - хранилище элементов инфоблока
+type Repository interface {
+	List(query Query) (ListResult, error)
+	Find(query Query) ([]*Element, error)
+	Stream(ctx context.Context, query Query, emit func(element *Element) error) error
+	Properties(elementID uint64) (map[string]*Property, error)
+	Path(elementID uint64) (*Path, error)
+}
+
+type repository struct {
+	conn      *sqlx.DB
+	products  catalog.Repository
+	uploadURL string
+	siteID    string
+}
+
+var fields = []string{
+	"ID",
+	"CODE",
+	"XML_ID ",
+	"NAME",
+	"IBLOCK_ID",
+	"IBLOCK_SECTION_ID",
+	"ACTIVE",
+	"ACTIVE_FROM",
+	"ACTIVE_TO",
+	"SORT",
+	"PREVIEW_PICTURE",
+	"PREVIEW_TEXT",
+	"DETAIL_PICTURE",
+	"DETAIL_TEXT",
+	"SEARCHABLE_CONTENT",
+	"DATE_CREATE",
+	"CREATED_BY",
+	"TIMESTAMP_X",
+	"MODIFIED_BY",
+	"SHOW_COUNTER",
+}
+
+// filterFields - поля, по которым можно фильтровать
+var filterFields = map[string]string{
+	"ID":                 "t.ID",
+	"CODE":               "t.CODE",
+	"XML_ID":             "t.XML_ID",
+	"NAME":               "t.NAME",
+	"IBLOCK_ID":          "t.IBLOCK_ID",
+	"IBLOCK_SECTION_ID":  "t.IBLOCK_SECTION_ID",
+	"ACTIVE":             "t.ACTIVE",
+	"ACTIVE_FROM":        "t.ACTIVE_FROM",
+	"ACTIVE_TO":          "t.ACTIVE_TO",
+	"SORT":               "t.SORT",
+	"PREVIEW_PICTURE":    "t.PREVIEW_PICTURE",
+	"PREVIEW_TEXT":       "t.PREVIEW_TEXT",
+	"DETAIL_PICTURE":     "t.DETAIL_PICTURE",
+	"DETAIL_TEXT":        "t.DETAIL_TEXT",
+	"SEARCHABLE_CONTENT": "t.SEARCHABLE_CONTENT",
+	"DATE_CREATE":        "t.DATE_CREATE",
+	"CREATED_BY":         "t.CREATED_BY",
+	"TIMESTAMP_X":        "t.TIMESTAMP_X",
+	"MODIFIED_BY":        "t.MODIFIED_BY",
+	"SHOW_COUNTER":       "t.SHOW_COUNTER",
+}
+
+// NewRepository - хранилище элементов поверх общего пула соединений, каталог нужен для expand catalog и prices,
+// uploadURL - начало src картинок и файлов, siteID - сайт для #SITE_DIR# в ссылках
+func NewRepository(conn *sqlx.DB, products catalog.Repository, uploadURL string, siteID string) Repository {
+	return &repository{conn: conn, products: products, uploadURL: uploadURL, siteID: siteID}
+}
+
+func (repo *repository) List(query Query) (result ListResult, errorMessage error) {
+	params, err := filter.ParseParams(query.Params, filterFields, "SORT ASC")
+	if err != nil {
+		errorMessage = err
+		return
+	}
+
+	selection, err := parseSelection(query)
+	if err != nil {
+		errorMessage = err
+		return
+	}
+
+	where, args, err := repo.prepareFilter(query.Filter)
+	if err != nil {
+		errorMessage = err
+		return
+	}
+
+	if params.Cursor {
+		result, errorMessage = repo.listByCursor(params, selection, where, args)
+		return
+	}
+
+	columns := selectColumns(selection)
+	if len(params.Group) > 0 {
+		columns, errorMessage = params.GroupColumns(selection, query.Expand)
+		if errorMessage != nil {
+			return
+		}
+		// у сгруппированных строк связей нет
+		selection = filter.Selection{Fields: selection.Fields}
+	}
+
+	total, err := repo.count(where, args, params.Group)
+	if err != nil {
+		errorMessage = err
+		return
+	}
+
+	result.Items, errorMessage = repo.find(where, args, params.SQL(), selection, columns)
+	if result.Items == nil {
+		result.Items = []*Element{}
+	}
+	result.Pagination = params.Pagination(total)
+
+	return
+}
+
+// listByCursor - страница после курсора, без подсчета общего количества
+func (repo *repository) listByCursor(params filter.Params, selection filter.Selection, where string, args []interface{}) (result ListResult, errorMessage error) {
+	where, args = params.WithKeyset(where, args)
+
+	result.Items, errorMessage = repo.find(where, args, params.SQL(), selection, selectColumns(selection)+params.CursorColumns())
+	if errorMessage != nil {
+		return
+	}
+	if result.Items == nil {
+		result.Items = []*Element{}
+	}
+
+	var nextCursor string
+	if len(result.Items) == params.Limit {
+		nextCursor, errorMessage = params.NextCursor(result.Items[len(result.Items)-1].Cursor)
+	}
+	result.Pagination = params.CursorPagination(nextCursor)
+
+	return
+}
+
+func (repo *repository) Find(query Query) (elements []*Element, errorMessage error) {
+	selection, err := parseSelection(query)
+	if err != nil {
+		errorMessage = err
+		return
+	}
+
+	where, args, err := repo.prepareFilter(query.Filter)
+	if err != nil {
+		errorMessage = err
+		return
+	}
+
+	elements, errorMessage = repo.find(where, args, "", selection, selectColumns(selection))
+
+	return
+}
+
+// count - общее количество записей по фильтру для пагинации
+func (repo *repository) count(where string, args []interface{}, group []string) (total int, errorMessage error) {
+	query := "SELECT COUNT(*) FROM `b_iblock_element` t " + where
+	if len(group) > 0 {
+		query = "SELECT COUNT(*) FROM (SELECT 1 FROM `b_iblock_element` t " + where +
+			" GROUP BY " + strings.Join(group, ", ") + ") g"
+	}
+
+	errorMessage = repo.conn.Get(&total, query, args...)
+
+	return
+}
+
+// find - записи по фильтру, columns - колонки SELECT (с CURSOR_VALUES при обходе по курсору)
+func (repo *repository) find(where string, args []interface{}, params string, selection filter.Selection, columns string) (elements []*Element, errorMessage error) {
+	table := " FROM `b_iblock_element` t "
+
+	query := "SELECT " + columns +
+		table +
+		where +
+		params
+
+	err := repo.conn.Select(&elements, query, args...)
+	if err != nil {
+		errorMessage = err
+		return
+	}
+
+	errorMessage = repo.enrich(elements, selection)
+
+	return
+}
+
+func (repo *repository) prepareFilter(rawFilter map[string]interface{}) (where string, values []interface{}, errorMessage error) {
+	// коды свойств уникальны в пределах инфоблока
+	var iblockID uint64
+	for _, key := range []string{"IBLOCK_ID", "=IBLOCK_ID"} {
+		if value, found := rawFilter[key]; found {
+			iblockID, _ = strconv.ParseUint(fmt.Sprint(value), 10, 64)
+		}
+	}
+
+	parser := filter.Parser{
+		Fields: filterFields,
+		Resolve: func(condition filter.Condition) (string, []interface{}, bool, error) {
+			return repo.propertyFilter(condition, iblockID)
+		},
+		Patterns: []string{propertyPrefix + "<CODE|ID>"},
+	}
+
+	condition, values, errorMessage := parser.Parse(rawFilter)
+	if condition != "" {
+		where = " WHERE " + condition
+	}
+
+	return
+}
+
+// Properties - свойства элемента с типами и значениями, nil - элемента нет
+func (repo *repository) Properties(elementID uint64) (props map[string]*Property, errorMessage error) {
+	elements, errorMessage := repo.Find(Query{
+		Filter: map[string]interface{}{"ID": elementID},
+		Select: []string{"ID", "IBLOCK_ID"},
+		Expand: []string{"properties"},
+	})
+	if errorMessage != nil || len(elements) == 0 {
+		return
+	}
+
+	props = elements[0].Props
+
+	return
+}
@@ -0,0 +1,186 @@
+package file
+
+import (
+	"context"
+	"errors"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/disintegration/imaging"
+
+	"../internal/iblock"
+)
+
+// cacheControl - картинки по ID не меняются (новый файл - новый ID), кэшируем надолго
+const cacheControl = "public, max-age=2592000"
+
+// errNoOriginal - файл есть в b_file, но его нет в папке upload
+var errNoOriginal = errors.New("original file not found")
+
+// Config - настройки картинок: папка upload сайта на диске, папка для готовых вариантов, допустимые
+// ширина и высота и сколько картинок можно пережимать одновременно
+type Config struct {
+	UploadDir string
+	CacheDir  string
+	Sizes     []int
+	Workers   int
+}
+
+// Handler - обработчики запросов к файлам
+type Handler struct {
+	repository Repository
+	config     Config
+	// workers - свободные места для пережатия, готовые варианты из кэша отдаются без очереди
+	workers chan struct{}
+}
+
+// NewHandler - создаем обработчики с переданным хранилищем и настройками картинок
+func NewHandler(repository Repository, config Config) *Handler {
+	if config.Workers < 1 {
+		config.Workers = 1
+	}
+
+	return &Handler{repository: repository, config: config, workers: make(chan struct{}, config.Workers)}
+}
+
+// Image - картинка из b_file с измененным размером и форматом, варианты хранятся в кэше на диске
+// по ID файла и параметрам, повторный запрос с If-None-Match получает 304
+func (handler *Handler) Image(response http.ResponseWriter, request *http.Request) {
+	requestURL := strings.Split(request.RequestURI, "/")
+	fileID, _ := strconv.ParseUint(requestURL[2], 10, 64)
+
+	options, err := parseOptions(request.URL.Query(), handler.config.Sizes)
+	if err != nil {
+		response.WriteHeader(http.StatusBadRequest)
+		response.Write([]byte(err.Error()))
+		return
+	}
+
+	file, err := handler.repository.File(fileID)
+	if err != nil {
+		response.WriteHeader(http.StatusBadRequest)
+		response.Write([]byte(err.Error()))
+		return
+	}
+	if file == nil {
+		response.WriteHeader(http.StatusNotFound)
+		response.Write([]byte("file not found"))
+		return
+	}
+	if !strings.HasPrefix(file.ContentType.String, "image/") {
+		response.WriteHeader(http.StatusBadRequest)
+		response.Write([]byte("file is not an image"))
+		return
+	}
+
+	key := options.key(file)
+	etag := `"` + key + `"`
+	response.Header().Set("ETag", etag)
+	response.Header().Set("Cache-Control", cacheControl)
+	if request.Header.Get("If-None-Match") == etag {
+		response.WriteHeader(http.StatusNotModified)
+		return
+	}
+
+	path, err := handler.variant(request.Context(), file, options, key)
+	if err == errNoOriginal {
+		response.WriteHeader(http.StatusNotFound)
+		response.Write([]byte(err.Error()))
+		return
+	}
+	if err != nil {
+		response.WriteHeader(http.StatusBadRequest)
+		response.Write([]byte(err.Error()))
+		return
+	}
+
+	cached, err := os.Open(path)
+	if err != nil {
+		response.WriteHeader(http.StatusBadRequest)
+		response.Write([]byte(err.Error()))
+		return
+	}
+	defer cached.Close()
+	info, err := cached.Stat()
+	if err != nil {
+		response.WriteHeader(http.StatusBadRequest)
+		response.Write([]byte(err.Error()))
+		return
+	}
+
+	response.Header().Set("Content-Type", options.contentType())
+	http.ServeContent(response, request, "", info.ModTime(), cached)
+}
+
+// variant - путь к готовой картинке в кэше, если ее нет - делаем из оригинала, не больше Workers одновременно.
+// Пишем во временный файл и переименовываем, чтобы параллельный запрос не прочитал недописанный файл
+func (handler *Handler) variant(ctx context.Context, file *iblock.File, options Options, key string) (path string, errorMessage error) {
+	dir := filepath.Join(handler.config.CacheDir, strconv.FormatUint(file.ID, 10))
+	path = filepath.Join(dir, key+"."+options.Format)
+	if _, err := os.Stat(path); err == nil {
+		return
+	}
+
+	select {
+	case handler.workers <- struct{}{}:
+		defer func() { <-handler.workers }()
+	case <-ctx.Done():
+		errorMessage = ctx.Err()
+		return
+	}
+	// пока ждали очереди, тот же вариант мог сделать параллельный запрос
+	if _, err := os.Stat(path); err == nil {
+		return
+	}
+
+	original, errorMessage := handler.originalPath(file)
+	if errorMessage != nil {
+		return
+	}
+	img, err := imaging.Open(original, imaging.AutoOrientation(true))
+	if os.IsNotExist(err) {
+		errorMessage = errNoOriginal
+		return
+	}
+	if err != nil {
+		errorMessage = err
+		return
+	}
+
+	errorMessage = os.MkdirAll(dir, 0755)
+	if errorMessage != nil {
+		return
+	}
+	temp, errorMessage := ioutil.TempFile(dir, "tmp-")
+	if errorMessage != nil {
+		return
+	}
+	defer os.Remove(temp.Name())
+
+	errorMessage = options.encode(temp, options.transform(img))
+	if closeErr := temp.Close(); errorMessage == nil {
+		errorMessage = closeErr
+	}
+	if errorMessage != nil {
+		return
+	}
+
+	errorMessage = os.Rename(temp.Name(), path)
+
+	return
+}
+
+// originalPath - путь к оригиналу в папке upload, SUBDIR и FILE_NAME не выводят за ее пределы
+func (handler *Handler) originalPath(file *iblock.File) (path string, errorMessage error) {
+	root := filepath.Clean(handler.config.UploadDir)
+	path = filepath.Join(root, file.SubDir, file.FileName)
+	if !strings.HasPrefix(path, root+string(filepath.Separator)) {
+		errorMessage = errNoOriginal
+	}
+
+	return
+}
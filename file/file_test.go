@@ -0,0 +1,134 @@
+package file
+
+import (
+	"image"
+	"image/jpeg"
+	"image/png"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"../internal/database"
+	"../internal/iblock"
+)
+
+type testRepository struct {
+	files map[uint64]*iblock.File
+}
+
+func (repo testRepository) File(fileID uint64) (*iblock.File, error) {
+	return repo.files[fileID], nil
+}
+
+func testFile(id uint64, subDir string, fileName string, contentType string) *iblock.File {
+	file := &iblock.File{ID: id, SubDir: subDir, FileName: fileName}
+	file.ContentType = database.NullString{}
+	file.ContentType.String, file.ContentType.Valid = contentType, true
+
+	return file
+}
+
+func TestOriginalPath(t *testing.T) {
+	handler := NewHandler(testRepository{}, Config{UploadDir: "/var/www/upload"})
+	tests := []struct {
+		name    string
+		subDir  string
+		file    string
+		want    string
+		wantErr bool
+	}{
+		{name: "inside upload", subDir: "iblock/abc", file: "a.jpg", want: "/var/www/upload/iblock/abc/a.jpg"},
+		{name: "empty subdir", subDir: "", file: "a.jpg", want: "/var/www/upload/a.jpg"},
+		{name: "subdir escapes", subDir: "../../etc", file: "passwd", wantErr: true},
+		{name: "file name escapes", subDir: "iblock", file: "../../../etc/passwd", wantErr: true},
+		{name: "upload dir itself", subDir: "", file: "", wantErr: true},
+		{name: "sibling with same prefix", subDir: "../upload2", file: "a.jpg", wantErr: true},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			got, err := handler.originalPath(testFile(1, test.subDir, test.file, "image/jpeg"))
+			if test.wantErr {
+				if err != errNoOriginal {
+					t.Fatalf("originalPath = %q, %v, want errNoOriginal", got, err)
+				}
+				return
+			}
+			if err != nil || got != test.want {
+				t.Errorf("originalPath = %q, %v, want %q", got, err, test.want)
+			}
+		})
+	}
+}
+
+func TestImage(t *testing.T) {
+	uploadDir := t.TempDir()
+	cacheDir := t.TempDir()
+	err := os.MkdirAll(filepath.Join(uploadDir, "iblock/abc"), 0755)
+	if err != nil {
+		t.Fatal(err)
+	}
+	original, err := os.Create(filepath.Join(uploadDir, "iblock/abc/a.png"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	png.Encode(original, image.NewNRGBA(image.Rect(0, 0, 800, 400)))
+	original.Close()
+
+	handler := NewHandler(testRepository{files: map[uint64]*iblock.File{
+		7: testFile(7, "iblock/abc", "a.png", "image/png"),
+		8: testFile(8, "iblock/abc", "missing.png", "image/png"),
+		9: testFile(9, "doc", "a.pdf", "application/pdf"),
+	}}, Config{UploadDir: uploadDir, CacheDir: cacheDir, Sizes: testSizes, Workers: 1})
+
+	serve := func(uri string, etag string) *httptest.ResponseRecorder {
+		request := httptest.NewRequest(http.MethodGet, uri, nil)
+		if etag != "" {
+			request.Header.Set("If-None-Match", etag)
+		}
+		response := httptest.NewRecorder()
+		handler.Image(response, request)
+		return response
+	}
+
+	response := serve("/file/7/?width=200", "")
+	if response.Code != http.StatusOK || response.Header().Get("Content-Type") != "image/jpeg" {
+		t.Fatalf("resize: %d %q %s", response.Code, response.Header().Get("Content-Type"), response.Body.String())
+	}
+	resized, err := jpeg.Decode(response.Body)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if resized.Bounds().Dx() != 200 || resized.Bounds().Dy() != 100 {
+		t.Errorf("resized to %v, want 200x100", resized.Bounds())
+	}
+	etag := response.Header().Get("ETag")
+	if etag == "" || response.Header().Get("Cache-Control") == "" {
+		t.Errorf("no cache headers: %v", response.Header())
+	}
+
+	cached, _ := filepath.Glob(filepath.Join(cacheDir, "7", "*.jpeg"))
+	if len(cached) != 1 {
+		t.Errorf("cached variants = %v, want one", cached)
+	}
+
+	if response = serve("/file/7/?width=200", etag); response.Code != http.StatusNotModified {
+		t.Errorf("If-None-Match: %d, want 304", response.Code)
+	}
+	if response = serve("/file/7/?width=400", ""); response.Header().Get("ETag") == etag {
+		t.Error("another size has the same ETag")
+	}
+
+	for uri, code := range map[string]int{
+		"/file/7/?width=250": http.StatusBadRequest,
+		"/file/9/":           http.StatusBadRequest,
+		"/file/8/":           http.StatusNotFound,
+		"/file/10/":          http.StatusNotFound,
+	} {
+		if response = serve(uri, ""); response.Code != code {
+			t.Errorf("%s: %d, want %d", uri, response.Code, code)
+		}
+	}
+}
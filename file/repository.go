@@ -0,0 +1,34 @@
+package file
+
+import (
+	"github.com/jmoiron/sqlx"
+
+	"../internal/iblock"
+)
+
+// Repository - хранилище файлов b_file
+type Repository interface {
+	File(fileID uint64) (*iblock.File, error)
+}
+
+type repository struct {
+	conn      *sqlx.DB
+	uploadURL string
+}
+
+// NewRepository - хранилище файлов поверх общего пула соединений, uploadURL - начало src файла
+func NewRepository(conn *sqlx.DB, uploadURL string) Repository {
+	return &repository{conn: conn, uploadURL: uploadURL}
+}
+
+// File - файл по ID, nil - файла нет в b_file
+func (repo *repository) File(fileID uint64) (file *iblock.File, errorMessage error) {
+	files, errorMessage := iblock.FindFiles(repo.conn, repo.uploadURL, []uint64{fileID})
+	if errorMessage != nil {
+		return
+	}
+
+	file = files[fileID]
+
+	return
+}
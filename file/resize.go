@@ -0,0 +1,197 @@
+package file
+
+import (
+	"crypto/sha1"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"image"
+	"image/color"
+	"image/jpeg"
+	"io"
+	"net/url"
+	"strconv"
+	"strings"
+
+	"github.com/disintegration/imaging"
+	// декодер webp для исходных картинок
+	_ "golang.org/x/image/webp"
+
+	"../internal/iblock"
+)
+
+// defaultQuality - качество jpeg и webp, если не передано
+const defaultQuality = 85
+
+// qualityStep - качество округляется вверх до шага, чтобы не плодить варианты в кэше
+const qualityStep = 10
+
+// fits - режимы вписывания: contain - в рамку с сохранением пропорций, cover - заполнить рамку и обрезать
+// по центру, fill - растянуть до размера
+var fits = []string{"contain", "cover", "fill"}
+
+// formats - форматы ответа, webp добавляется в webp.go, если сборка с cgo
+var formats = []string{"jpeg"}
+
+// Options - параметры картинки из строки запроса (?width=300&height=200&fit=cover&format=webp&quality=80)
+type Options struct {
+	Width   int
+	Height  int
+	Fit     string
+	Format  string
+	Quality int
+}
+
+// parseOptions - параметры картинки, 0 в ширине или высоте - по пропорциям оригинала,
+// остальные размеры - только из списка sizes
+func parseOptions(values url.Values, sizes []int) (options Options, errorMessage error) {
+	options = Options{Fit: "contain", Format: "jpeg", Quality: defaultQuality}
+
+	options.Width, errorMessage = sizeParam(values, "width", sizes)
+	if errorMessage != nil {
+		return
+	}
+	options.Height, errorMessage = sizeParam(values, "height", sizes)
+	if errorMessage != nil {
+		return
+	}
+	if value := values.Get("quality"); value != "" {
+		options.Quality, errorMessage = intParam(values, "quality", 1, 100)
+		if errorMessage != nil {
+			return
+		}
+		options.Quality = (options.Quality + qualityStep - 1) / qualityStep * qualityStep
+	}
+
+	if value := strings.ToLower(values.Get("fit")); value != "" {
+		if !contains(fits, value) {
+			errorMessage = errors.New("unknown fit " + value + ", allowed: " + strings.Join(fits, ", "))
+			return
+		}
+		options.Fit = value
+	}
+	if options.Fit != "contain" && (options.Width == 0 || options.Height == 0) {
+		errorMessage = errors.New("fit " + options.Fit + " needs width and height")
+		return
+	}
+
+	if value := strings.ToLower(values.Get("format")); value != "" {
+		if value == "jpg" {
+			value = "jpeg"
+		}
+		if !contains(formats, value) {
+			errorMessage = errors.New("unknown format " + value + ", allowed: " + strings.Join(formats, ", "))
+			return
+		}
+		options.Format = value
+	}
+
+	return
+}
+
+// key - ключ кэша и ETag: файл (с размером, чтобы замена файла давала новый ключ) и параметры
+func (options Options) key(file *iblock.File) string {
+	hash := sha1.Sum([]byte(fmt.Sprintf("%d|%s|%s|%d|%d|%d|%s|%s|%d",
+		file.ID, file.SubDir, file.FileName, file.FileSize.Int64,
+		options.Width, options.Height, options.Fit, options.Format, options.Quality)))
+
+	return hex.EncodeToString(hash[:])
+}
+
+// contentType - тип ответа по формату
+func (options Options) contentType() string {
+	return "image/" + options.Format
+}
+
+// transform - меняем размер картинки по режиму вписывания, contain не увеличивает картинку
+func (options Options) transform(img image.Image) image.Image {
+	bounds := img.Bounds()
+
+	switch options.Fit {
+	case "cover":
+		return imaging.Fill(img, options.Width, options.Height, imaging.Center, imaging.Lanczos)
+	case "fill":
+		return imaging.Resize(img, options.Width, options.Height, imaging.Lanczos)
+	}
+
+	if options.Width == 0 && options.Height == 0 {
+		return img
+	}
+	width, height := options.Width, options.Height
+	if width == 0 {
+		width = bounds.Dx()
+	}
+	if height == 0 {
+		height = bounds.Dy()
+	}
+
+	return imaging.Fit(img, width, height, imaging.Lanczos)
+}
+
+// encode - картинка в формате ответа, прозрачность в jpeg заливается белым
+func (options Options) encode(writer io.Writer, img image.Image) error {
+	if options.Format == "webp" {
+		return encodeWebp(writer, img, options.Quality)
+	}
+
+	bounds := img.Bounds()
+	background := imaging.New(bounds.Dx(), bounds.Dy(), color.White)
+	flat := imaging.Overlay(background, img, image.Pt(0, 0), 1)
+
+	return jpeg.Encode(writer, flat, &jpeg.Options{Quality: options.Quality})
+}
+
+// sizeParam - ширина или высота из списка допустимых размеров
+func sizeParam(values url.Values, name string, sizes []int) (result int, errorMessage error) {
+	value := values.Get(name)
+	if value == "" {
+		return
+	}
+
+	result, err := strconv.Atoi(value)
+	if err == nil && (result == 0 || containsInt(sizes, result)) {
+		return
+	}
+
+	allowed := make([]string, 0, len(sizes))
+	for _, size := range sizes {
+		allowed = append(allowed, strconv.Itoa(size))
+	}
+	errorMessage = errors.New(name + " must be one of: " + strings.Join(allowed, ", "))
+
+	return
+}
+
+func intParam(values url.Values, name string, min int, max int) (result int, errorMessage error) {
+	value := values.Get(name)
+	if value == "" {
+		return
+	}
+
+	result, err := strconv.Atoi(value)
+	if err != nil || result < min || result > max {
+		errorMessage = errors.New(name + " must be from " + strconv.Itoa(min) + " to " + strconv.Itoa(max))
+	}
+
+	return
+}
+
+func containsInt(list []int, value int) bool {
+	for _, item := range list {
+		if item == value {
+			return true
+		}
+	}
+
+	return false
+}
+
+func contains(list []string, value string) bool {
+	for _, item := range list {
+		if item == value {
+			return true
+		}
+	}
+
+	return false
+}
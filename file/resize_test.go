@@ -0,0 +1,94 @@
+package file
+
+import (
+	"net/url"
+	"testing"
+
+	"../internal/database"
+	"../internal/iblock"
+)
+
+var testSizes = []int{100, 200, 400}
+
+func TestParseOptions(t *testing.T) {
+	tests := []struct {
+		name    string
+		query   string
+		want    Options
+		wantErr bool
+	}{
+		{name: "defaults", query: "", want: Options{Fit: "contain", Format: "jpeg", Quality: 85}},
+		{name: "allowed sizes", query: "width=200&height=100", want: Options{Width: 200, Height: 100, Fit: "contain", Format: "jpeg", Quality: 85}},
+		{name: "zero keeps proportions", query: "width=0&height=400", want: Options{Height: 400, Fit: "contain", Format: "jpeg", Quality: 85}},
+		{name: "size not in list", query: "width=201", wantErr: true},
+		{name: "negative size", query: "height=-100", wantErr: true},
+		{name: "not a number", query: "width=abc", wantErr: true},
+		{name: "quality rounded up to step", query: "quality=81", want: Options{Fit: "contain", Format: "jpeg", Quality: 90}},
+		{name: "quality min", query: "quality=1", want: Options{Fit: "contain", Format: "jpeg", Quality: 10}},
+		{name: "quality out of range", query: "quality=101", wantErr: true},
+		{name: "cover with both sizes", query: "width=100&height=100&fit=COVER", want: Options{Width: 100, Height: 100, Fit: "cover", Format: "jpeg", Quality: 85}},
+		{name: "cover needs both sizes", query: "width=100&fit=cover", wantErr: true},
+		{name: "fill needs both sizes", query: "fit=fill", wantErr: true},
+		{name: "unknown fit", query: "fit=stretch", wantErr: true},
+		{name: "jpg alias", query: "format=jpg", want: Options{Fit: "contain", Format: "jpeg", Quality: 85}},
+		// без cgo webp нет в formats
+		{name: "webp", query: "format=webp", want: Options{Fit: "contain", Format: "webp", Quality: 85}, wantErr: !contains(formats, "webp")},
+		{name: "unknown format", query: "format=gif", wantErr: true},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			values, _ := url.ParseQuery(test.query)
+			got, err := parseOptions(values, testSizes)
+			if test.wantErr {
+				if err == nil {
+					t.Fatalf("parseOptions(%q) = %+v, want error", test.query, got)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("parseOptions(%q) error: %v", test.query, err)
+			}
+			if got != test.want {
+				t.Errorf("parseOptions(%q) = %+v, want %+v", test.query, got, test.want)
+			}
+		})
+	}
+}
+
+func TestOptionsKey(t *testing.T) {
+	file := &iblock.File{ID: 7, SubDir: "iblock/abc", FileName: "a.jpg", FileSize: database.NullInt64{}}
+	file.FileSize.Int64, file.FileSize.Valid = 1024, true
+	base := Options{Width: 200, Fit: "contain", Format: "jpeg", Quality: 80}
+
+	if base.key(file) != base.key(file) {
+		t.Fatal("key is not stable for the same file and options")
+	}
+	if len(base.key(file)) != 40 {
+		t.Errorf("key %q is not a sha1 hex", base.key(file))
+	}
+
+	changedOptions := []Options{
+		{Width: 400, Fit: "contain", Format: "jpeg", Quality: 80},
+		{Width: 200, Height: 200, Fit: "contain", Format: "jpeg", Quality: 80},
+		{Width: 200, Fit: "fill", Format: "jpeg", Quality: 80},
+		{Width: 200, Fit: "contain", Format: "webp", Quality: 80},
+		{Width: 200, Fit: "contain", Format: "jpeg", Quality: 90},
+	}
+	for _, options := range changedOptions {
+		if options.key(file) == base.key(file) {
+			t.Errorf("options %+v give the same key as %+v", options, base)
+		}
+	}
+
+	replaced := *file
+	replaced.FileSize.Int64 = 2048
+	if base.key(&replaced) == base.key(file) {
+		t.Error("replaced file with another size gives the same key")
+	}
+	other := *file
+	other.ID = 8
+	if base.key(&other) == base.key(file) {
+		t.Error("another file ID gives the same key")
+	}
+}
@@ -0,0 +1,20 @@
+//go:build cgo
+// +build cgo
+
+package file
+
+import (
+	"image"
+	"io"
+
+	"github.com/chai2010/webp"
+)
+
+func init() {
+	formats = append(formats, "webp")
+}
+
+// encodeWebp - картинка в webp, кодировщик github.com/chai2010/webp собирается через cgo с libwebp
+func encodeWebp(writer io.Writer, img image.Image, quality int) error {
+	return webp.Encode(writer, img, &webp.Options{Quality: float32(quality)})
+}
@@ -0,0 +1,15 @@
+//go:build !cgo
+// +build !cgo
+
+package file
+
+import (
+	"errors"
+	"image"
+	"io"
+)
+
+// encodeWebp - без cgo кодировщика webp нет, формат не попадает в formats и сюда не доходит
+func encodeWebp(writer io.Writer, img image.Image, quality int) error {
+	return errors.New("webp is not supported, build with CGO_ENABLED=1 and libwebp")
+}
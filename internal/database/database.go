@@ -0,0 +1,100 @@
+// Package database - общее подключение к базе битрикса и типы для ее полей
+package database
+
+import (
+	"io/ioutil"
+	"runtime"
+	"strconv"
+	"time"
+
+	// драйвер mysql для sqlx
+	_ "github.com/go-sql-driver/mysql"
+	"github.com/jmoiron/sqlx"
+	yaml "gopkg.in/yaml.v2"
+)
+
+// Env - структура для данных из env.yml файла
+type Env struct {
+	DBName            string        `yaml:"db_name"`
+	DBLogin           string        `yaml:"db_login"`
+	DBPassword        string        `yaml:"db_password"`
+	DBHost            string        `yaml:"db_host"`
+	DBPort            int           `yaml:"db_port"`
+	DBMaxOpenConns    int           `yaml:"db_max_open_conns"`
+	DBMaxIdleConns    int           `yaml:"db_max_idle_conns"`
+	DBConnMaxLifetime time.Duration `yaml:"db_conn_max_lifetime"`
+	DBConnMaxIdleTime time.Duration `yaml:"db_conn_max_idle_time"`
+	SiteID            string        `yaml:"site_id"`
+	UploadURL         string        `yaml:"upload_url"`
+	UploadDir         string        `yaml:"upload_dir"`
+	ResizeCacheDir    string        `yaml:"resize_cache_dir"`
+	ResizeSizes       []int         `yaml:"resize_sizes"`
+	ResizeWorkers     int           `yaml:"resize_workers"`
+}
+
+// LoadEnv - читаем env.yml и подставляем значения по умолчанию
+func LoadEnv(path string) (env Env, errorMessage error) {
+	fileEnv, err := ioutil.ReadFile(path)
+	if err != nil {
+		errorMessage = err
+		return
+	}
+
+	err = yaml.Unmarshal(fileEnv, &env)
+	if err != nil {
+		errorMessage = err
+		return
+	}
+
+	if env.DBMaxOpenConns == 0 {
+		env.DBMaxOpenConns = 20
+	}
+	if env.DBMaxIdleConns == 0 {
+		env.DBMaxIdleConns = 10
+	}
+	if env.DBConnMaxLifetime == 0 {
+		env.DBConnMaxLifetime = 5 * time.Minute
+	}
+	if env.SiteID == "" {
+		env.SiteID = "s1"
+	}
+	if env.UploadURL == "" {
+		env.UploadURL = "/upload/"
+	}
+	if env.UploadDir == "" {
+		env.UploadDir = "upload"
+	}
+	if env.ResizeCacheDir == "" {
+		env.ResizeCacheDir = "resize_cache"
+	}
+	if len(env.ResizeSizes) == 0 {
+		env.ResizeSizes = []int{100, 200, 300, 400, 600, 800, 1200}
+	}
+	if env.ResizeWorkers == 0 {
+		env.ResizeWorkers = runtime.NumCPU()
+	}
+
+	return
+}
+
+// ConnectString - строка подключения для драйвера mysql
+func (env *Env) ConnectString() string {
+	return env.DBLogin + ":" + env.DBPassword +
+		"@tcp(" + env.DBHost + ":" + strconv.Itoa(env.DBPort) + ")/" + env.DBName
+}
+
+// Open - открываем пул соединений, общий для всех пакетов
+func Open(env Env) (conn *sqlx.DB, errorMessage error) {
+	conn, err := sqlx.Connect("mysql", env.ConnectString())
+	if err != nil {
+		errorMessage = err
+		return
+	}
+
+	conn.SetMaxOpenConns(env.DBMaxOpenConns)
+	conn.SetMaxIdleConns(env.DBMaxIdleConns)
+	conn.SetConnMaxLifetime(env.DBConnMaxLifetime)
+	conn.SetConnMaxIdleTime(env.DBConnMaxIdleTime)
+
+	return
+}
@@ -0,0 +1,154 @@
+package database
+
+import (
+	"database/sql"
+	"encoding/json"
+)
+
+// NullInt64 - целое поле, null отдается как 0
+type NullInt64 struct {
+	sql.NullInt64
+}
+
+// NullFloat64 - дробное поле, null отдается как 0
+type NullFloat64 struct {
+	sql.NullFloat64
+}
+
+// NullString - строковое поле, null отдается как пустая строка
+type NullString struct {
+	sql.NullString
+}
+
+// Bool - битриксовый флаг Y/N
+type Bool struct {
+	sql.NullString
+}
+
+// NewBool - флаг из go значения
+func NewBool(value bool) Bool {
+	if value {
+		return Bool{sql.NullString{String: "Y", Valid: true}}
+	}
+
+	return Bool{sql.NullString{String: "N", Valid: true}}
+}
+
+// IsTrue - флаг установлен (все кроме N считается Y, как и при выводе в json)
+func (r Bool) IsTrue() bool {
+	return r.String != "N"
+}
+
+// MarshalJSON MarshalJSON interface redefinition
+func (r NullInt64) MarshalJSON() ([]byte, error) {
+	if r.Valid {
+		return json.Marshal(r.Int64)
+	}
+
+	return json.Marshal(0)
+
+}
+
+// UnmarshalJSON UnmarshalJSON interface redefinition
+func (r *NullInt64) UnmarshalJSON(data []byte) error {
+	var value *int64
+	err := json.Unmarshal(data, &value)
+	if err != nil {
+		return err
+	}
+
+	r.Valid = value != nil
+	r.Int64 = 0
+	if value != nil {
+		r.Int64 = *value
+	}
+
+	return nil
+}
+
+// MarshalJSON MarshalJSON interface redefinition
+func (r NullFloat64) MarshalJSON() ([]byte, error) {
+	if r.Valid {
+		return json.Marshal(r.Float64)
+	}
+
+	return json.Marshal(0)
+
+}
+
+// UnmarshalJSON UnmarshalJSON interface redefinition
+func (r *NullFloat64) UnmarshalJSON(data []byte) error {
+	var value *float64
+	err := json.Unmarshal(data, &value)
+	if err != nil {
+		return err
+	}
+
+	r.Valid = value != nil
+	r.Float64 = 0
+	if value != nil {
+		r.Float64 = *value
+	}
+
+	return nil
+}
+
+// MarshalJSON MarshalJSON interface redefinition
+func (r NullString) MarshalJSON() ([]byte, error) {
+	if r.Valid {
+		return json.Marshal(r.String)
+	}
+
+	return json.Marshal("")
+
+}
+
+// UnmarshalJSON UnmarshalJSON interface redefinition
+func (r *NullString) UnmarshalJSON(data []byte) error {
+	var value *string
+	err := json.Unmarshal(data, &value)
+	if err != nil {
+		return err
+	}
+
+	r.Valid = value != nil
+	r.String = ""
+	if value != nil {
+		r.String = *value
+	}
+
+	return nil
+}
+
+// MarshalJSON MarshalJSON interface redefinition
+func (r Bool) MarshalJSON() ([]byte, error) {
+	if r.String == "N" {
+		return json.Marshal(false)
+	}
+
+	return json.Marshal(true)
+
+}
+
+// UnmarshalJSON UnmarshalJSON interface redefinition, принимает true/false и "Y"/"N"
+func (r *Bool) UnmarshalJSON(data []byte) error {
+	if string(data) == "null" {
+		*r = Bool{}
+		return nil
+	}
+
+	var flag bool
+	if json.Unmarshal(data, &flag) == nil {
+		*r = NewBool(flag)
+		return nil
+	}
+
+	var value string
+	err := json.Unmarshal(data, &value)
+	if err != nil {
+		return err
+	}
+	*r = NewBool(value != "N")
+
+	return nil
+}
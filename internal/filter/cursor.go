@@ -0,0 +1,225 @@
+package filter
+
+import (
+	"database/sql"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"strings"
+)
+
+// cursor - содержимое токена: сортировка, для которой он выдан, и ее значения у последней строки
+type cursor struct {
+	Order  []string  `json:"o"`
+	Values []*string `json:"v"`
+}
+
+// orderSignature - сортировка в виде списка "FIELD DIRECTION" для сверки с курсором
+func (params *Params) orderSignature() (signature []string) {
+	for _, sort := range params.Order {
+		signature = append(signature, sort.Field+" "+sort.Direction)
+	}
+
+	return
+}
+
+// parseCursor - разбираем токен CURSOR, пустая строка - первая страница
+func (params *Params) parseCursor(token string) (errorMessage error) {
+	params.Cursor = true
+	if token == "" {
+		return
+	}
+
+	data, err := base64.RawURLEncoding.DecodeString(token)
+	if err != nil {
+		errorMessage = errors.New("invalid CURSOR")
+		return
+	}
+
+	var decoded cursor
+	err = json.Unmarshal(data, &decoded)
+	if err != nil {
+		errorMessage = errors.New("invalid CURSOR")
+		return
+	}
+	if strings.Join(decoded.Order, ",") != strings.Join(params.orderSignature(), ",") {
+		errorMessage = errors.New("CURSOR was issued for ORDER " + strings.Join(decoded.Order, ", "))
+		return
+	}
+	if len(decoded.Values) != len(params.Order) {
+		errorMessage = errors.New("invalid CURSOR")
+		return
+	}
+
+	params.after = decoded.Values
+
+	return
+}
+
+// Keyset - условие "строки после курсора" для WHERE, пустая строка на первой странице.
+// NULL в mysql идет первым при ASC и последним при DESC
+func (params *Params) Keyset() (where string, args []interface{}) {
+	if params.after == nil {
+		return
+	}
+
+	var alternatives []string
+	var equal []string
+	var equalArgs []interface{}
+	for index, sort := range params.Order {
+		value := params.after[index]
+
+		var after string
+		var afterArgs []interface{}
+		switch {
+		case sort.Direction == "ASC" && value == nil:
+			after = sort.Column + " IS NOT NULL"
+		case sort.Direction == "ASC":
+			after = sort.Column + " > ?"
+			afterArgs = []interface{}{*value}
+		case value == nil:
+			after = ""
+		default:
+			after = "(" + sort.Column + " < ? OR " + sort.Column + " IS NULL)"
+			afterArgs = []interface{}{*value}
+		}
+
+		if after != "" {
+			alternatives = append(alternatives, "("+strings.Join(append(append([]string{}, equal...), after), " AND ")+")")
+			args = append(args, equalArgs...)
+			args = append(args, afterArgs...)
+		}
+
+		if value == nil {
+			equal = append(equal, sort.Column+" IS NULL")
+		} else {
+			equal = append(equal, sort.Column+" = ?")
+			equalArgs = append(equalArgs, *value)
+		}
+	}
+
+	if len(alternatives) == 0 {
+		where = "1 = 0"
+		return
+	}
+	where = "(" + strings.Join(alternatives, " OR ") + ")"
+
+	return
+}
+
+// CursorColumn - колонка со значениями сортировки строки для следующего курсора
+const CursorColumn = "CURSOR_VALUES"
+
+// CursorValues - значения сортировки строки из колонки CURSOR_VALUES
+type CursorValues []sql.NullString
+
+// Scan - разбираем CONCAT_WS из CursorColumns: hex текстового значения через запятую, NULL как есть
+func (values *CursorValues) Scan(value interface{}) error {
+	var text string
+	switch typed := value.(type) {
+	case []byte:
+		text = string(typed)
+	case string:
+		text = typed
+	default:
+		return errors.New("unsupported " + CursorColumn + " type")
+	}
+
+	*values = nil
+	for _, part := range strings.Split(text, ",") {
+		if part == "NULL" {
+			*values = append(*values, sql.NullString{})
+			continue
+		}
+
+		decoded, err := hex.DecodeString(part)
+		if err != nil {
+			return errors.New("invalid " + CursorColumn + " value")
+		}
+		*values = append(*values, sql.NullString{String: string(decoded), Valid: true})
+	}
+
+	return nil
+}
+
+// CursorColumns - колонка CURSOR_VALUES для SELECT при обходе по курсору, пустая строка без курсора.
+// Значения сортировки берутся из той же строки, что попала на страницу, отдельный запрос за ними не нужен
+func (params *Params) CursorColumns() string {
+	if !params.Cursor {
+		return ""
+	}
+
+	var values []string
+	for _, sort := range params.Order {
+		values = append(values, "IFNULL(HEX(CAST("+sort.Column+" AS CHAR)), 'NULL')")
+	}
+
+	return ", CONCAT_WS(',', " + strings.Join(values, ", ") + ") AS " + CursorColumn
+}
+
+// NextCursor - токен для страницы после строки со значениями сортировки last (колонка CURSOR_VALUES)
+func (params *Params) NextCursor(last CursorValues) (token string, errorMessage error) {
+	if len(last) != len(params.Order) {
+		errorMessage = errors.New(CursorColumn + " was not selected")
+		return
+	}
+
+	return params.encodeCursor(last)
+}
+
+// Continue - переходим к странице после строки со значениями сортировки last, OFFSET действует только на первой
+func (params *Params) Continue(last CursorValues) (errorMessage error) {
+	if len(last) != len(params.Order) {
+		errorMessage = errors.New(CursorColumn + " was not selected")
+		return
+	}
+
+	params.Cursor = true
+	params.Page = 0
+	params.Offset = 0
+	params.after = make([]*string, len(last))
+	for index := range last {
+		if last[index].Valid {
+			value := last[index].String
+			params.after[index] = &value
+		}
+	}
+
+	return
+}
+
+// encodeCursor - токен из значений сортировки последней строки
+func (params *Params) encodeCursor(values []sql.NullString) (token string, errorMessage error) {
+	encoded := cursor{Order: params.orderSignature()}
+	for index := range values {
+		if values[index].Valid {
+			value := values[index].String
+			encoded.Values = append(encoded.Values, &value)
+		} else {
+			encoded.Values = append(encoded.Values, nil)
+		}
+	}
+
+	data, err := json.Marshal(encoded)
+	if err != nil {
+		errorMessage = err
+		return
+	}
+	token = base64.RawURLEncoding.EncodeToString(data)
+
+	return
+}
+
+// WithKeyset - добавляем условие курсора к готовому " WHERE ..." (или пустой строке)
+func (params *Params) WithKeyset(where string, args []interface{}) (string, []interface{}) {
+	keyset, keysetArgs := params.Keyset()
+	if keyset == "" {
+		return where, args
+	}
+	if where == "" {
+		return " WHERE " + keyset, keysetArgs
+	}
+
+	return where + " AND " + keyset, append(append([]interface{}{}, args...), keysetArgs...)
+}
@@ -0,0 +1,298 @@
+package filter
+
+import (
+	"database/sql"
+	"encoding/base64"
+	"encoding/hex"
+	"reflect"
+	"testing"
+)
+
+// cursorParams - params с ORDER order и курсором на строку со значениями values
+func cursorParams(t *testing.T, order string, values ...sql.NullString) Params {
+	t.Helper()
+	first, err := ParseParams(map[string]interface{}{"ORDER": order, "CURSOR": ""}, testFields, "SORT ASC")
+	if err != nil {
+		t.Fatal(err)
+	}
+	token, err := first.encodeCursor(values)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	params, err := ParseParams(map[string]interface{}{"ORDER": order, "CURSOR": token}, testFields, "SORT ASC")
+	if err != nil {
+		t.Fatalf("ParseParams with CURSOR for %s: %v", order, err)
+	}
+
+	return params
+}
+
+func value(text string) sql.NullString {
+	return sql.NullString{String: text, Valid: true}
+}
+
+var null = sql.NullString{}
+
+func TestKeyset(t *testing.T) {
+	tests := []struct {
+		name      string
+		order     string
+		values    []sql.NullString
+		wantWhere string
+		wantArgs  []interface{}
+	}{
+		{
+			name:      "asc",
+			order:     "SORT ASC",
+			values:    []sql.NullString{value("10"), value("5")},
+			wantWhere: "((t.SORT > ?) OR (t.SORT = ? AND t.ID > ?))",
+			wantArgs:  []interface{}{"10", "10", "5"},
+		},
+		{
+			name:      "desc",
+			order:     "SORT DESC, ID DESC",
+			values:    []sql.NullString{value("10"), value("5")},
+			wantWhere: "(((t.SORT < ? OR t.SORT IS NULL)) OR (t.SORT = ? AND (t.ID < ? OR t.ID IS NULL)))",
+			wantArgs:  []interface{}{"10", "10", "5"},
+		},
+		{
+			name:      "desc then asc",
+			order:     "NAME DESC",
+			values:    []sql.NullString{value("b"), value("7")},
+			wantWhere: "(((t.NAME < ? OR t.NAME IS NULL)) OR (t.NAME = ? AND t.ID > ?))",
+			wantArgs:  []interface{}{"b", "b", "7"},
+		},
+		{
+			name:      "asc then desc then asc",
+			order:     "SORT ASC, NAME DESC",
+			values:    []sql.NullString{value("1"), value("b"), value("7")},
+			wantWhere: "((t.SORT > ?) OR (t.SORT = ? AND (t.NAME < ? OR t.NAME IS NULL)) OR (t.SORT = ? AND t.NAME = ? AND t.ID > ?))",
+			wantArgs:  []interface{}{"1", "1", "b", "1", "b", "7"},
+		},
+		{
+			name:      "null asc: not null values are later",
+			order:     "SORT ASC",
+			values:    []sql.NullString{null, value("5")},
+			wantWhere: "((t.SORT IS NOT NULL) OR (t.SORT IS NULL AND t.ID > ?))",
+			wantArgs:  []interface{}{"5"},
+		},
+		{
+			name:      "null desc: only other nulls are later",
+			order:     "NAME DESC",
+			values:    []sql.NullString{null, value("5")},
+			wantWhere: "((t.NAME IS NULL AND t.ID > ?))",
+			wantArgs:  []interface{}{"5"},
+		},
+		{
+			name:      "null desc last: nothing is later",
+			order:     "ID ASC, NAME DESC",
+			values:    []sql.NullString{value("5"), null},
+			wantWhere: "((t.ID > ?))",
+			wantArgs:  []interface{}{"5"},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			params := cursorParams(t, test.order, test.values...)
+			where, args := params.Keyset()
+			if where != test.wantWhere {
+				t.Errorf("Keyset() where = %q, want %q", where, test.wantWhere)
+			}
+			if !reflect.DeepEqual(args, test.wantArgs) {
+				t.Errorf("Keyset() args = %#v, want %#v", args, test.wantArgs)
+			}
+		})
+	}
+}
+
+func TestKeysetNothingAfter(t *testing.T) {
+	params := Params{Order: []Sort{{"NAME", "t.NAME", "DESC"}}, after: []*string{nil}}
+	if where, args := params.Keyset(); where != "1 = 0" || args != nil {
+		t.Errorf("Keyset() after last null DESC = %q %v, want 1 = 0", where, args)
+	}
+}
+
+func TestWithKeyset(t *testing.T) {
+	first, err := ParseParams(map[string]interface{}{"CURSOR": ""}, testFields, "SORT ASC")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !first.Cursor {
+		t.Error("empty CURSOR does not switch to cursor pagination")
+	}
+	where, args := first.WithKeyset(" WHERE t.ACTIVE = ?", []interface{}{"Y"})
+	if where != " WHERE t.ACTIVE = ?" || !reflect.DeepEqual(args, []interface{}{"Y"}) {
+		t.Errorf("WithKeyset on first page = %q %v", where, args)
+	}
+
+	params := cursorParams(t, "SORT ASC", value("10"), value("5"))
+
+	where, args = params.WithKeyset("", nil)
+	if where != " WHERE ((t.SORT > ?) OR (t.SORT = ? AND t.ID > ?))" {
+		t.Errorf("WithKeyset without where = %q", where)
+	}
+	if !reflect.DeepEqual(args, []interface{}{"10", "10", "5"}) {
+		t.Errorf("WithKeyset without where args = %v", args)
+	}
+
+	filterArgs := []interface{}{"Y"}
+	where, args = params.WithKeyset(" WHERE t.ACTIVE = ?", filterArgs)
+	if where != " WHERE t.ACTIVE = ? AND ((t.SORT > ?) OR (t.SORT = ? AND t.ID > ?))" {
+		t.Errorf("WithKeyset with where = %q", where)
+	}
+	if !reflect.DeepEqual(args, []interface{}{"Y", "10", "10", "5"}) {
+		t.Errorf("WithKeyset with where args = %v", args)
+	}
+	if len(filterArgs) != 1 {
+		t.Errorf("WithKeyset changed filter args: %v", filterArgs)
+	}
+}
+
+func TestCursorRoundTrip(t *testing.T) {
+	params := cursorParams(t, "NAME DESC, SORT", value("шкаф \"1\""), null, value("42"))
+	if !params.Cursor || params.Page != 0 {
+		t.Errorf("cursor params Cursor = %v Page = %d", params.Cursor, params.Page)
+	}
+
+	want := []*string{}
+	for _, text := range []string{"шкаф \"1\"", "", "42"} {
+		if text == "" {
+			want = append(want, nil)
+			continue
+		}
+		copied := text
+		want = append(want, &copied)
+	}
+	if !reflect.DeepEqual(params.after, want) {
+		t.Errorf("decoded cursor values do not match encoded ones")
+	}
+}
+
+func TestParseCursorErrors(t *testing.T) {
+	params := Params{Order: []Sort{{"SORT", "t.SORT", "ASC"}, {"ID", "t.ID", "ASC"}}}
+	valid, err := params.encodeCursor([]sql.NullString{value("10"), value("5")})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	encode := func(text string) string {
+		return base64.RawURLEncoding.EncodeToString([]byte(text))
+	}
+
+	tests := []struct {
+		name  string
+		token string
+	}{
+		{name: "not base64", token: "***"},
+		{name: "padded base64", token: valid + "=="},
+		{name: "truncated", token: valid[:len(valid)-3]},
+		{name: "not json", token: encode("garbage")},
+		{name: "wrong json type", token: encode(`{"o": "SORT ASC", "v": []}`)},
+		{name: "other order", token: encode(`{"o": ["SORT DESC", "ID ASC"], "v": ["10", "5"]}`)},
+		{name: "order injection", token: encode(`{"o": ["SORT ASC", "ID ASC; DROP"], "v": ["10", "5"]}`)},
+		{name: "too few values", token: encode(`{"o": ["SORT ASC", "ID ASC"], "v": ["10"]}`)},
+		{name: "too many values", token: encode(`{"o": ["SORT ASC", "ID ASC"], "v": ["10", "5", "1"]}`)},
+		{name: "no values", token: encode(`{"o": ["SORT ASC", "ID ASC"]}`)},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			parsed := Params{Order: params.Order}
+			if err := parsed.parseCursor(test.token); err == nil {
+				t.Errorf("parseCursor(%q) want error", test.token)
+			}
+			if parsed.after != nil {
+				t.Errorf("parseCursor(%q) kept values %v", test.token, parsed.after)
+			}
+		})
+	}
+
+	parsed := Params{Order: params.Order}
+	if err := parsed.parseCursor(valid); err != nil {
+		t.Errorf("parseCursor(valid) error: %v", err)
+	}
+}
+
+func TestCursorParamsErrors(t *testing.T) {
+	tests := []string{
+		`{"CURSOR": "", "PAGE": 2}`,
+		`{"CURSOR": "", "OFFSET": 10}`,
+		`{"CURSOR": "", "OFFSET": 0}`,
+		`{"CURSOR": "", "GROUP": "ACTIVE"}`,
+		`{"CURSOR": 1}`,
+		`{"CURSOR": null}`,
+		`{"CURSOR": "bm90IGpzb24"}`,
+	}
+
+	for _, raw := range tests {
+		if _, err := ParseParams(decode(t, raw), testFields, "SORT ASC"); err == nil {
+			t.Errorf("ParseParams(%s) want error", raw)
+		}
+	}
+
+	// курсор, выданный для другой сортировки
+	first := Params{Order: []Sort{{"SORT", "t.SORT", "ASC"}, {"ID", "t.ID", "ASC"}}}
+	token, err := first.encodeCursor([]sql.NullString{value("10"), value("5")})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err = ParseParams(map[string]interface{}{"CURSOR": token, "ORDER": "NAME"}, testFields, "SORT ASC"); err == nil {
+		t.Error("CURSOR issued for other ORDER want error")
+	}
+}
+
+func TestCursorColumns(t *testing.T) {
+	params, err := ParseParams(map[string]interface{}{"ORDER": "NAME DESC", "CURSOR": ""}, testFields, "SORT ASC")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := ", CONCAT_WS(',', IFNULL(HEX(CAST(t.NAME AS CHAR)), 'NULL'), IFNULL(HEX(CAST(t.ID AS CHAR)), 'NULL')) AS CURSOR_VALUES"
+	if columns := params.CursorColumns(); columns != want {
+		t.Errorf("CursorColumns() = %q, want %q", columns, want)
+	}
+
+	params.Cursor = false
+	if columns := params.CursorColumns(); columns != "" {
+		t.Errorf("CursorColumns() without CURSOR = %q, want empty", columns)
+	}
+}
+
+func TestNextCursor(t *testing.T) {
+	first, err := ParseParams(map[string]interface{}{"ORDER": "NAME DESC, SORT", "CURSOR": ""}, testFields, "SORT ASC")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// строка со значениями "шкаф, 1", NULL и 42 так, как ее отдает CONCAT_WS из CursorColumns
+	var last CursorValues
+	err = last.Scan([]byte(hex.EncodeToString([]byte("шкаф, 1")) + ",NULL," + hex.EncodeToString([]byte("42"))))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(last, CursorValues{value("шкаф, 1"), null, value("42")}) {
+		t.Fatalf("scanned %v", last)
+	}
+
+	token, err := first.NextCursor(last)
+	if err != nil {
+		t.Fatal(err)
+	}
+	params, err := ParseParams(map[string]interface{}{"ORDER": "NAME DESC, SORT", "CURSOR": token}, testFields, "SORT ASC")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if params.after[0] == nil || *params.after[0] != "шкаф, 1" || params.after[1] != nil ||
+		params.after[2] == nil || *params.after[2] != "42" {
+		t.Errorf("next page starts after %v", params.after)
+	}
+
+	if _, err := first.NextCursor(nil); err == nil {
+		t.Error("NextCursor without CURSOR_VALUES: want error")
+	}
+	if err := last.Scan("zz"); err == nil {
+		t.Error("Scan of invalid hex: want error")
+	}
+}
@@ -0,0 +1,289 @@
+// Package filter - разбор фильтров в стиле битрикса в параметризованный sql
+package filter
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// Condition - одно условие фильтра: [!]оператор, поле и значения
+type Condition struct {
+	Key      string
+	Field    string
+	Operator string
+	Negate   bool
+	Null     bool
+	Values   []interface{}
+}
+
+// Parser - разбор фильтра по белому списку полей
+type Parser struct {
+	// Fields - поле фильтра -> выражение в sql
+	Fields map[string]string
+	// Resolve - условия для полей не из Fields (например свойства), ok = false - поле неизвестно
+	Resolve func(condition Condition) (where string, args []interface{}, ok bool, errorMessage error)
+	// Patterns - описание полей, которые понимает Resolve, для сообщения об ошибке
+	Patterns []string
+}
+
+// UnknownFieldError - поле фильтра не из белого списка
+type UnknownFieldError struct {
+	Field   string
+	Allowed []string
+}
+
+func (err *UnknownFieldError) Error() string {
+	return "unknown filter field " + err.Field + ", allowed: " + strings.Join(err.Allowed, ", ")
+}
+
+// operators - префиксы условий, более длинные проверяются первыми
+var operators = []struct {
+	prefix   string
+	operator string
+	negate   bool
+}{
+	{"!><", "><", true},
+	{"><", "><", false},
+	{"!=", "=", true},
+	{"!%", "%", true},
+	{"!?", "?", true},
+	{">=", ">=", false},
+	{"<=", "<=", false},
+	{"=", "=", false},
+	{"!", "=", true},
+	{">", ">", false},
+	{"<", "<", false},
+	{"%", "%", false},
+	{"?", "?", false},
+}
+
+// Parse - условие для WHERE (без самого WHERE) и его аргументы, пустой фильтр - пустая строка
+func (parser *Parser) Parse(filter map[string]interface{}) (where string, args []interface{}, errorMessage error) {
+	return parser.group(filter)
+}
+
+// group - условия одного уровня, объединенные по LOGIC (AND по умолчанию)
+func (parser *Parser) group(filter map[string]interface{}) (where string, args []interface{}, errorMessage error) {
+	logic := "AND"
+	if value, found := filter["LOGIC"]; found {
+		logic = strings.ToUpper(fmt.Sprint(value))
+		if logic != "AND" && logic != "OR" {
+			errorMessage = errors.New("LOGIC must be AND or OR")
+			return
+		}
+	}
+
+	keys := make([]string, 0, len(filter))
+	for key := range filter {
+		if key != "LOGIC" {
+			keys = append(keys, key)
+		}
+	}
+	sort.Strings(keys)
+
+	var conditions []string
+	for _, key := range keys {
+		var condition string
+		var conditionArgs []interface{}
+		var err error
+
+		if nested, ok := filter[key].(map[string]interface{}); ok {
+			condition, conditionArgs, err = parser.group(nested)
+		} else {
+			condition, conditionArgs, err = parser.condition(key, filter[key])
+		}
+		if err != nil {
+			errorMessage = err
+			return
+		}
+		if condition == "" {
+			continue
+		}
+		conditions = append(conditions, condition)
+		args = append(args, conditionArgs...)
+	}
+
+	if len(conditions) == 1 {
+		where = conditions[0]
+	} else if len(conditions) > 1 {
+		where = "(" + strings.Join(conditions, " "+logic+" ") + ")"
+	}
+
+	return
+}
+
+func (parser *Parser) condition(key string, value interface{}) (where string, args []interface{}, errorMessage error) {
+	condition, errorMessage := NewCondition(key, value)
+	if errorMessage != nil {
+		return
+	}
+
+	if column, found := parser.Fields[condition.Field]; found {
+		where, args, errorMessage = condition.SQL(column)
+		if errorMessage == nil && condition.Negate {
+			where = "NOT (" + where + ")"
+		}
+		return
+	}
+
+	if parser.Resolve != nil {
+		var ok bool
+		where, args, ok, errorMessage = parser.Resolve(condition)
+		if ok || errorMessage != nil {
+			return
+		}
+	}
+
+	errorMessage = &UnknownFieldError{Field: condition.Field, Allowed: parser.allowed()}
+
+	return
+}
+
+func (parser *Parser) allowed() (allowed []string) {
+	for field := range parser.Fields {
+		allowed = append(allowed, field)
+	}
+	sort.Strings(allowed)
+	allowed = append(allowed, parser.Patterns...)
+
+	return
+}
+
+// NewCondition - разбираем ключ с префиксом оператора и значение
+func NewCondition(key string, value interface{}) (condition Condition, errorMessage error) {
+	condition = Condition{Key: key, Field: key, Operator: "="}
+	for _, operator := range operators {
+		if strings.HasPrefix(key, operator.prefix) {
+			condition.Field = key[len(operator.prefix):]
+			condition.Operator = operator.operator
+			condition.Negate = operator.negate
+			break
+		}
+	}
+	if condition.Field == "" {
+		errorMessage = errors.New("empty filter field in " + key)
+		return
+	}
+
+	if value == nil {
+		condition.Null = true
+		if condition.Operator != "=" {
+			errorMessage = errors.New("null is allowed only for = and ! in " + key)
+		}
+		return
+	}
+
+	if list, ok := value.([]interface{}); ok {
+		for _, item := range list {
+			condition.Values = append(condition.Values, scalar(item))
+		}
+	} else {
+		condition.Values = []interface{}{scalar(value)}
+	}
+
+	switch condition.Operator {
+	case "><":
+		if len(condition.Values) != 2 {
+			errorMessage = errors.New("operator >< needs two values in " + key)
+		}
+	case ">", ">=", "<", "<=":
+		if len(condition.Values) != 1 {
+			errorMessage = errors.New("operator " + condition.Operator + " needs a single value in " + key)
+		}
+	default:
+		if len(condition.Values) == 0 {
+			errorMessage = errors.New("empty value list in " + key)
+		}
+	}
+
+	return
+}
+
+// SQL - условие без учета отрицания для колонки column
+func (condition *Condition) SQL(column string) (where string, args []interface{}, errorMessage error) {
+	if condition.Null {
+		where = column + " IS NULL"
+		return
+	}
+
+	switch condition.Operator {
+	case "=":
+		if len(condition.Values) == 1 {
+			where = column + " = ?"
+		} else {
+			where = column + " IN (?" + strings.Repeat(", ?", len(condition.Values)-1) + ")"
+		}
+		args = condition.Values
+	case ">", ">=", "<", "<=":
+		where = column + " " + condition.Operator + " ?"
+		args = condition.Values
+	case "><":
+		where = column + " BETWEEN ? AND ?"
+		args = condition.Values
+	case "%":
+		var likes []string
+		for _, value := range condition.Values {
+			likes = append(likes, column+" LIKE ?")
+			args = append(args, "%"+escapeLike(fmt.Sprint(value))+"%")
+		}
+		where = "(" + strings.Join(likes, " OR ") + ")"
+	case "?":
+		where, args = searchSQL(column, condition.Values)
+	default:
+		errorMessage = errors.New("unsupported operator " + condition.Operator)
+	}
+
+	return
+}
+
+// searchSQL - логический поиск подстрок: "a | b" - любая, "a & b" - все
+func searchSQL(column string, values []interface{}) (where string, args []interface{}) {
+	var ors []string
+	for _, value := range values {
+		for _, alternative := range strings.Split(fmt.Sprint(value), "|") {
+			var ands []string
+			for _, word := range strings.Split(alternative, "&") {
+				word = strings.TrimSpace(word)
+				if word == "" {
+					continue
+				}
+				ands = append(ands, column+" LIKE ?")
+				args = append(args, "%"+escapeLike(word)+"%")
+			}
+			if len(ands) > 0 {
+				ors = append(ors, "("+strings.Join(ands, " AND ")+")")
+			}
+		}
+	}
+	if len(ors) == 0 {
+		where = "1 = 1"
+		return
+	}
+	where = "(" + strings.Join(ors, " OR ") + ")"
+
+	return
+}
+
+// scalar - значение из json в аргумент запроса, флаги true/false -> Y/N
+func scalar(value interface{}) interface{} {
+	switch typed := value.(type) {
+	case bool:
+		if typed {
+			return "Y"
+		}
+		return "N"
+	case json.Number:
+		return typed.String()
+	case string:
+		return typed
+	}
+
+	return fmt.Sprint(value)
+}
+
+func escapeLike(value string) string {
+	return strings.NewReplacer("\\", "\\\\", "%", "\\%", "_", "\\_").Replace(value)
+}
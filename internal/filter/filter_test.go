@@ -0,0 +1,203 @@
+package filter
+
+import (
+	"encoding/json"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+var testFields = map[string]string{
+	"ID":        "t.ID",
+	"NAME":      "t.NAME",
+	"SORT":      "t.SORT",
+	"ACTIVE":    "t.ACTIVE",
+	"IBLOCK_ID": "t.IBLOCK_ID",
+}
+
+// decode - фильтр из json, как его получает обработчик
+func decode(t *testing.T, raw string) map[string]interface{} {
+	t.Helper()
+	decoder := json.NewDecoder(strings.NewReader(raw))
+	decoder.UseNumber()
+	var filter map[string]interface{}
+	if err := decoder.Decode(&filter); err != nil {
+		t.Fatalf("decode %s: %v", raw, err)
+	}
+
+	return filter
+}
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		name      string
+		filter    string
+		wantWhere string
+		wantArgs  []interface{}
+	}{
+		{name: "empty", filter: `{}`, wantWhere: ""},
+		{name: "equal", filter: `{"ID": 10}`, wantWhere: "t.ID = ?", wantArgs: []interface{}{"10"}},
+		{name: "equal prefix", filter: `{"=ID": "10"}`, wantWhere: "t.ID = ?", wantArgs: []interface{}{"10"}},
+		{name: "in", filter: `{"ID": [1, 2, 3]}`, wantWhere: "t.ID IN (?, ?, ?)", wantArgs: []interface{}{"1", "2", "3"}},
+		{name: "not equal", filter: `{"!ID": 10}`, wantWhere: "NOT (t.ID = ?)", wantArgs: []interface{}{"10"}},
+		{name: "not equal explicit", filter: `{"!=ID": 10}`, wantWhere: "NOT (t.ID = ?)", wantArgs: []interface{}{"10"}},
+		{name: "not in", filter: `{"!ID": [1, 2]}`, wantWhere: "NOT (t.ID IN (?, ?))", wantArgs: []interface{}{"1", "2"}},
+		{name: "greater", filter: `{">SORT": 100}`, wantWhere: "t.SORT > ?", wantArgs: []interface{}{"100"}},
+		{name: "greater or equal", filter: `{">=SORT": 100}`, wantWhere: "t.SORT >= ?", wantArgs: []interface{}{"100"}},
+		{name: "less", filter: `{"<SORT": 100}`, wantWhere: "t.SORT < ?", wantArgs: []interface{}{"100"}},
+		{name: "less or equal", filter: `{"<=SORT": 100}`, wantWhere: "t.SORT <= ?", wantArgs: []interface{}{"100"}},
+		{name: "between", filter: `{"><SORT": [100, 500]}`, wantWhere: "t.SORT BETWEEN ? AND ?", wantArgs: []interface{}{"100", "500"}},
+		{name: "not between", filter: `{"!><SORT": [100, 500]}`, wantWhere: "NOT (t.SORT BETWEEN ? AND ?)", wantArgs: []interface{}{"100", "500"}},
+		{name: "like", filter: `{"%NAME": "шкаф"}`, wantWhere: "(t.NAME LIKE ?)", wantArgs: []interface{}{"%шкаф%"}},
+		{name: "like escapes wildcards", filter: `{"%NAME": "50%_a\\b"}`, wantWhere: "(t.NAME LIKE ?)", wantArgs: []interface{}{`%50\%\_a\\b%`}},
+		{name: "like any", filter: `{"%NAME": ["a", "b"]}`, wantWhere: "(t.NAME LIKE ? OR t.NAME LIKE ?)", wantArgs: []interface{}{"%a%", "%b%"}},
+		{name: "not like", filter: `{"!%NAME": "a"}`, wantWhere: "NOT ((t.NAME LIKE ?))", wantArgs: []interface{}{"%a%"}},
+		{name: "search", filter: `{"?NAME": "a | b & c"}`, wantWhere: "((t.NAME LIKE ?) OR (t.NAME LIKE ? AND t.NAME LIKE ?))",
+			wantArgs: []interface{}{"%a%", "%b%", "%c%"}},
+		{name: "not search", filter: `{"!?NAME": "a"}`, wantWhere: "NOT (((t.NAME LIKE ?)))", wantArgs: []interface{}{"%a%"}},
+		{name: "empty search", filter: `{"?NAME": " | "}`, wantWhere: "1 = 1"},
+		{name: "null", filter: `{"NAME": null}`, wantWhere: "t.NAME IS NULL"},
+		{name: "not null", filter: `{"!NAME": null}`, wantWhere: "NOT (t.NAME IS NULL)"},
+		{name: "bool", filter: `{"ACTIVE": true, "!IBLOCK_ID": false}`, wantWhere: "(NOT (t.IBLOCK_ID = ?) AND t.ACTIVE = ?)",
+			wantArgs: []interface{}{"N", "Y"}},
+		{name: "and by default, keys sorted", filter: `{"SORT": 1, "ID": 2}`, wantWhere: "(t.ID = ? AND t.SORT = ?)",
+			wantArgs: []interface{}{"2", "1"}},
+		{name: "nested or", filter: `{"ID": 1, "group": {"LOGIC": "OR", "NAME": "a", "SORT": 2}}`,
+			wantWhere: "(t.ID = ? AND (t.NAME = ? OR t.SORT = ?))", wantArgs: []interface{}{"1", "a", "2"}},
+		{name: "top level or", filter: `{"LOGIC": "or", "ID": 1, "SORT": 2}`, wantWhere: "(t.ID = ? OR t.SORT = ?)",
+			wantArgs: []interface{}{"1", "2"}},
+		{name: "nested and in or", filter: `{"LOGIC": "OR", "a": {"ID": 1, "SORT": 2}, "b": {"ID": 3}}`,
+			wantWhere: "((t.ID = ? AND t.SORT = ?) OR t.ID = ?)", wantArgs: []interface{}{"1", "2", "3"}},
+		{name: "empty nested group skipped", filter: `{"ID": 1, "group": {}}`, wantWhere: "t.ID = ?", wantArgs: []interface{}{"1"}},
+	}
+
+	parser := Parser{Fields: testFields}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			where, args, err := parser.Parse(decode(t, test.filter))
+			if err != nil {
+				t.Fatalf("Parse(%s) error: %v", test.filter, err)
+			}
+			if where != test.wantWhere {
+				t.Errorf("Parse(%s) where = %q, want %q", test.filter, where, test.wantWhere)
+			}
+			if !reflect.DeepEqual(args, test.wantArgs) {
+				t.Errorf("Parse(%s) args = %#v, want %#v", test.filter, args, test.wantArgs)
+			}
+		})
+	}
+}
+
+func TestParseErrors(t *testing.T) {
+	tests := []struct {
+		name    string
+		filter  string
+		unknown bool
+	}{
+		{name: "unknown field", filter: `{"PASSWORD": 1}`, unknown: true},
+		{name: "unknown field with operator", filter: `{">=PASSWORD": 1}`, unknown: true},
+		{name: "injection in field", filter: `{"ID = 1 OR 1": 1}`, unknown: true},
+		{name: "lower case field", filter: `{"id": 1}`, unknown: true},
+		{name: "unknown field in nested group", filter: `{"group": {"LOGIC": "OR", "ID": 1, "X": 2}}`, unknown: true},
+		{name: "unknown property pattern", filter: `{"PROPERTY_COLOR": "red"}`, unknown: true},
+		{name: "empty field", filter: `{"!": 1}`},
+		{name: "bad logic", filter: `{"LOGIC": "XOR", "ID": 1}`},
+		{name: "null with comparison", filter: `{">SORT": null}`},
+		{name: "null with like", filter: `{"%NAME": null}`},
+		{name: "between needs two values", filter: `{"><SORT": [1]}`},
+		{name: "between scalar", filter: `{"><SORT": 1}`},
+		{name: "comparison with list", filter: `{">SORT": [1, 2]}`},
+		{name: "empty list", filter: `{"ID": []}`},
+	}
+
+	// Resolve понимает только PROPERTY_SIZE, остальные свойства - неизвестные поля
+	parser := Parser{
+		Fields: testFields,
+		Resolve: func(condition Condition) (string, []interface{}, bool, error) {
+			if condition.Field != "PROPERTY_SIZE" {
+				return "", nil, false, nil
+			}
+			return "p.VALUE = ?", condition.Values, true, nil
+		},
+		Patterns: []string{"PROPERTY_<CODE|ID>"},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			where, args, err := parser.Parse(decode(t, test.filter))
+			if err == nil {
+				t.Fatalf("Parse(%s) = %q %v, want error", test.filter, where, args)
+			}
+			if where != "" {
+				t.Errorf("Parse(%s) returned sql %q with error", test.filter, where)
+			}
+
+			unknown, isUnknown := err.(*UnknownFieldError)
+			if isUnknown != test.unknown {
+				t.Fatalf("Parse(%s) error %T %v, unknown field error = %v", test.filter, err, err, test.unknown)
+			}
+			if isUnknown && !strings.Contains(unknown.Error(), "PROPERTY_<CODE|ID>") {
+				t.Errorf("unknown field error %q does not list property patterns", unknown.Error())
+			}
+		})
+	}
+}
+
+func TestParseResolve(t *testing.T) {
+	parser := Parser{
+		Fields: testFields,
+		Resolve: func(condition Condition) (string, []interface{}, bool, error) {
+			if condition.Field != "PROPERTY_SIZE" {
+				return "", nil, false, nil
+			}
+			return "p.VALUE = ?", condition.Values, true, nil
+		},
+	}
+
+	where, args, err := parser.Parse(decode(t, `{"ID": 1, "PROPERTY_SIZE": "XL"}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if where != "(t.ID = ? AND p.VALUE = ?)" || !reflect.DeepEqual(args, []interface{}{"1", "XL"}) {
+		t.Errorf("Parse = %q %#v", where, args)
+	}
+}
+
+func TestNewCondition(t *testing.T) {
+	tests := []struct {
+		key      string
+		field    string
+		operator string
+		negate   bool
+	}{
+		{"ID", "ID", "=", false},
+		{"=ID", "ID", "=", false},
+		{"!ID", "ID", "=", true},
+		{"!=ID", "ID", "=", true},
+		{">ID", "ID", ">", false},
+		{">=ID", "ID", ">=", false},
+		{"<ID", "ID", "<", false},
+		{"<=ID", "ID", "<=", false},
+		{"><ID", "ID", "><", false},
+		{"!><ID", "ID", "><", true},
+		{"%ID", "ID", "%", false},
+		{"!%ID", "ID", "%", true},
+		{"?ID", "ID", "?", false},
+		{"!?ID", "ID", "?", true},
+	}
+
+	for _, test := range tests {
+		value := interface{}("1")
+		if test.operator == "><" {
+			value = []interface{}{"1", "2"}
+		}
+		condition, err := NewCondition(test.key, value)
+		if err != nil {
+			t.Errorf("NewCondition(%q) error: %v", test.key, err)
+			continue
+		}
+		if condition.Field != test.field || condition.Operator != test.operator || condition.Negate != test.negate {
+			t.Errorf("NewCondition(%q) = %s %s negate=%v, want %s %s negate=%v", test.key,
+				condition.Field, condition.Operator, condition.Negate, test.field, test.operator, test.negate)
+		}
+	}
+}
@@ -0,0 +1,324 @@
+package filter
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"../../api"
+)
+
+const (
+	// DefaultLimit - количество записей, если LIMIT не задан
+	DefaultLimit = 100
+	// MaxLimit - максимальный LIMIT, больше не отдаем за один запрос
+	MaxLimit = 1000
+)
+
+// Sort - одно поле сортировки
+type Sort struct {
+	Field     string
+	Column    string
+	Direction string
+}
+
+// Params - разобранный блок params: сортировка, группировка и страница
+type Params struct {
+	Order  []Sort
+	Group  []string
+	Limit  int
+	Offset int
+	Page   int
+	// Cursor - постраничный обход по курсору вместо OFFSET
+	Cursor bool
+	after  []*string
+	// groupFields - поля GROUP в том же порядке, что и колонки Group
+	groupFields []string
+}
+
+// Pagination - информация о странице для ответа списка, тип общий с client
+type Pagination = api.Pagination
+
+// ParseParams - разбираем params по белому списку полей, defaultOrder - сортировка по умолчанию ("SORT ASC")
+func ParseParams(raw map[string]interface{}, fields map[string]string, defaultOrder string) (params Params, errorMessage error) {
+	return parseParams(raw, fields, defaultOrder, false)
+}
+
+// ParseStreamParams - params для потоковой выдачи: LIMIT необязателен и не ограничен сверху
+func ParseStreamParams(raw map[string]interface{}, fields map[string]string, defaultOrder string) (params Params, errorMessage error) {
+	return parseParams(raw, fields, defaultOrder, true)
+}
+
+func parseParams(raw map[string]interface{}, fields map[string]string, defaultOrder string, stream bool) (params Params, errorMessage error) {
+	params.Limit = DefaultLimit
+	params.Page = 1
+	if stream {
+		params.Limit = 0
+	}
+
+	if value, found := raw["LIMIT"]; found {
+		params.Limit, errorMessage = integer("LIMIT", value)
+		if errorMessage != nil {
+			return
+		}
+		if params.Limit <= 0 || (!stream && params.Limit > MaxLimit) {
+			errorMessage = errors.New("LIMIT must be between 1 and " + strconv.Itoa(MaxLimit))
+			return
+		}
+	}
+
+	if value, found := raw["PAGE"]; found {
+		params.Page, errorMessage = integer("PAGE", value)
+		if errorMessage != nil {
+			return
+		}
+		if params.Page < 1 {
+			errorMessage = errors.New("PAGE must be positive")
+			return
+		}
+		if params.Limit == 0 {
+			errorMessage = errors.New("PAGE needs LIMIT")
+			return
+		}
+		params.Offset = (params.Page - 1) * params.Limit
+	} else if value, found := raw["OFFSET"]; found {
+		params.Offset, errorMessage = integer("OFFSET", value)
+		if errorMessage != nil {
+			return
+		}
+		if params.Offset < 0 {
+			errorMessage = errors.New("OFFSET must not be negative")
+			return
+		}
+		if params.Limit > 0 {
+			params.Page = params.Offset/params.Limit + 1
+		}
+	}
+
+	if value, found := raw["GROUP"]; found {
+		if stream {
+			// потоковая выдача идет пачками по курсору, а курсор по сгруппированным строкам не строится
+			errorMessage = errors.New("GROUP can not be used with streaming")
+			return
+		}
+		var group []string
+		group, errorMessage = stringList("GROUP", value)
+		if errorMessage != nil {
+			return
+		}
+		for _, field := range group {
+			column, found := fields[strings.ToUpper(field)]
+			if !found {
+				errorMessage = &UnknownFieldError{Field: field, Allowed: (&Parser{Fields: fields}).allowed()}
+				return
+			}
+			params.Group = append(params.Group, column)
+			params.groupFields = append(params.groupFields, strings.ToUpper(field))
+		}
+	}
+
+	// при GROUP сортировать можно только по сгруппированным полям, по умолчанию - по ним же
+	order := []string{defaultOrder}
+	if len(params.groupFields) > 0 {
+		order = params.groupFields
+	}
+	if value, found := raw["ORDER"]; found {
+		order, errorMessage = stringList("ORDER", value)
+		if errorMessage != nil {
+			return
+		}
+	}
+	params.Order, errorMessage = parseOrder(order, fields, params.groupFields)
+	if errorMessage != nil {
+		return
+	}
+
+	if value, found := raw["CURSOR"]; found {
+		token, ok := value.(string)
+		if !ok {
+			errorMessage = errors.New("CURSOR must be a string")
+			return
+		}
+		_, hasPage := raw["PAGE"]
+		_, hasOffset := raw["OFFSET"]
+		_, hasGroup := raw["GROUP"]
+		if hasPage || hasOffset || hasGroup {
+			errorMessage = errors.New("CURSOR can not be combined with PAGE, OFFSET or GROUP")
+			return
+		}
+		params.Page = 0
+		errorMessage = params.parseCursor(token)
+		if errorMessage != nil {
+			return
+		}
+	}
+
+	for key := range raw {
+		switch key {
+		case "LIMIT", "PAGE", "OFFSET", "ORDER", "GROUP", "CURSOR":
+		default:
+			errorMessage = errors.New("unknown param " + key + ", allowed: LIMIT, PAGE, OFFSET, ORDER, GROUP, CURSOR")
+			return
+		}
+	}
+
+	return
+}
+
+// parseOrder - "SORT ASC, ID DESC" в список полей, ID добавляется в конец для стабильных страниц.
+// При группировке поля сортировки должны быть из group, ID добавляется, только если по нему группируют
+func parseOrder(order []string, fields map[string]string, group []string) (sorts []Sort, errorMessage error) {
+	var hasID bool
+	for _, item := range order {
+		for _, part := range strings.Split(item, ",") {
+			words := strings.Fields(part)
+			if len(words) == 0 {
+				continue
+			}
+			if len(words) > 2 {
+				errorMessage = errors.New("invalid ORDER " + part)
+				return
+			}
+
+			field := strings.ToUpper(words[0])
+			column, found := fields[field]
+			if !found {
+				errorMessage = &UnknownFieldError{Field: field, Allowed: (&Parser{Fields: fields}).allowed()}
+				return
+			}
+			if len(group) > 0 && !contains(group, field) {
+				errorMessage = errors.New("ORDER field " + field + " is not in GROUP")
+				return
+			}
+
+			direction := "ASC"
+			if len(words) == 2 {
+				direction = strings.ToUpper(words[1])
+				if direction != "ASC" && direction != "DESC" {
+					errorMessage = errors.New("ORDER direction must be ASC or DESC in " + part)
+					return
+				}
+			}
+
+			sorts = append(sorts, Sort{Field: field, Column: column, Direction: direction})
+			hasID = hasID || field == "ID"
+		}
+	}
+
+	if column, found := fields["ID"]; found && !hasID && (len(group) == 0 || contains(group, "ID")) {
+		sorts = append(sorts, Sort{Field: "ID", Column: column, Direction: "ASC"})
+	}
+
+	return
+}
+
+// SQL - GROUP BY, ORDER BY и LIMIT для конца запроса, все значения уже проверены
+func (params *Params) SQL() (query string) {
+	if len(params.Group) > 0 {
+		query += " GROUP BY " + strings.Join(params.Group, ", ")
+	}
+	if len(params.Order) > 0 {
+		var order []string
+		for _, sort := range params.Order {
+			order = append(order, sort.Column+" "+sort.Direction)
+		}
+		query += " ORDER BY " + strings.Join(order, ", ")
+	}
+	switch {
+	case params.Limit > 0:
+		query += " LIMIT " + strconv.Itoa(params.Limit)
+	case params.Offset > 0:
+		// mysql не умеет OFFSET без LIMIT
+		query += " LIMIT 18446744073709551615"
+	}
+	if params.Offset > 0 {
+		query += " OFFSET " + strconv.Itoa(params.Offset)
+	}
+
+	return
+}
+
+// GroupColumns - SELECT для запроса с GROUP: сгруппированные колонки и MIN(ID) как ID группы.
+// Под ONLY_FULL_GROUP_BY остальные колонки не выбрать, поэтому select должен быть задан и не выходить за GROUP,
+// expand - переданные связи, у сгруппированных строк их нет
+func (params *Params) GroupColumns(selection Selection, expand []string) (columns string, errorMessage error) {
+	if len(selection.Fields) == 0 {
+		errorMessage = errors.New("GROUP needs select of grouped fields: " + strings.Join(params.groupFields, ", "))
+		return
+	}
+	for _, field := range selection.Fields {
+		if field != "ID" && !contains(params.groupFields, field) {
+			errorMessage = errors.New("select field " + field + " is not in GROUP")
+			return
+		}
+	}
+	if len(expand) > 0 {
+		errorMessage = errors.New("expand can not be used with GROUP")
+		return
+	}
+
+	columns = strings.Join(params.Group, ", ")
+	if !contains(params.groupFields, "ID") {
+		columns += ", MIN(`t`.ID) AS ID"
+	}
+
+	return
+}
+
+// Pagination - информация о странице при общем количестве total
+func (params *Params) Pagination(total int) (pagination Pagination) {
+	pagination = Pagination{
+		Total:  &total,
+		Page:   params.Page,
+		Limit:  params.Limit,
+		Offset: params.Offset,
+	}
+	if params.Offset+params.Limit < total {
+		nextPage := params.Page + 1
+		nextOffset := params.Offset + params.Limit
+		pagination.NextPage = &nextPage
+		pagination.NextOffset = &nextOffset
+	}
+
+	return
+}
+
+// CursorPagination - информация о странице при обходе по курсору, пустой nextCursor - страниц больше нет
+func (params *Params) CursorPagination(nextCursor string) (pagination Pagination) {
+	pagination = Pagination{Limit: params.Limit}
+	if nextCursor != "" {
+		pagination.NextCursor = &nextCursor
+	}
+
+	return
+}
+
+func integer(key string, value interface{}) (number int, errorMessage error) {
+	number, err := strconv.Atoi(fmt.Sprint(value))
+	if err != nil {
+		errorMessage = errors.New(key + " must be an integer")
+	}
+
+	return
+}
+
+func stringList(key string, value interface{}) (list []string, errorMessage error) {
+	switch typed := value.(type) {
+	case string:
+		list = []string{typed}
+	case []interface{}:
+		for _, item := range typed {
+			text, ok := item.(string)
+			if !ok {
+				errorMessage = errors.New(key + " must be a string or a list of strings")
+				return
+			}
+			list = append(list, text)
+		}
+	default:
+		errorMessage = errors.New(key + " must be a string or a list of strings")
+	}
+
+	return
+}
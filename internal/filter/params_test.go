@@ -0,0 +1,224 @@
+package filter
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestParseParams(t *testing.T) {
+	tests := []struct {
+		name      string
+		params    string
+		wantSQL   string
+		wantPage  int
+		wantLimit int
+	}{
+		{name: "defaults", params: `{}`, wantSQL: " ORDER BY t.SORT ASC, t.ID ASC LIMIT 100", wantPage: 1, wantLimit: 100},
+		{name: "limit", params: `{"LIMIT": 10}`, wantSQL: " ORDER BY t.SORT ASC, t.ID ASC LIMIT 10", wantPage: 1, wantLimit: 10},
+		{name: "limit as string", params: `{"LIMIT": "10"}`, wantSQL: " ORDER BY t.SORT ASC, t.ID ASC LIMIT 10", wantPage: 1, wantLimit: 10},
+		{name: "max limit", params: `{"LIMIT": 1000}`, wantSQL: " ORDER BY t.SORT ASC, t.ID ASC LIMIT 1000", wantPage: 1, wantLimit: 1000},
+		{name: "page", params: `{"LIMIT": 10, "PAGE": 3}`, wantSQL: " ORDER BY t.SORT ASC, t.ID ASC LIMIT 10 OFFSET 20", wantPage: 3, wantLimit: 10},
+		{name: "offset", params: `{"LIMIT": 10, "OFFSET": 25}`, wantSQL: " ORDER BY t.SORT ASC, t.ID ASC LIMIT 10 OFFSET 25", wantPage: 3, wantLimit: 10},
+		{name: "page wins over offset", params: `{"LIMIT": 10, "PAGE": 2, "OFFSET": 55}`, wantSQL: " ORDER BY t.SORT ASC, t.ID ASC LIMIT 10 OFFSET 10", wantPage: 2, wantLimit: 10},
+		{name: "order string", params: `{"ORDER": "name desc, sort"}`, wantSQL: " ORDER BY t.NAME DESC, t.SORT ASC, t.ID ASC LIMIT 100", wantPage: 1, wantLimit: 100},
+		{name: "order list", params: `{"ORDER": ["NAME ASC", "ID DESC"]}`, wantSQL: " ORDER BY t.NAME ASC, t.ID DESC LIMIT 100", wantPage: 1, wantLimit: 100},
+		{name: "group", params: `{"GROUP": ["iblock_id"], "ORDER": "IBLOCK_ID DESC"}`, wantSQL: " GROUP BY t.IBLOCK_ID ORDER BY t.IBLOCK_ID DESC LIMIT 100", wantPage: 1, wantLimit: 100},
+		{name: "group string", params: `{"GROUP": "ACTIVE"}`, wantSQL: " GROUP BY t.ACTIVE ORDER BY t.ACTIVE ASC LIMIT 100", wantPage: 1, wantLimit: 100},
+		{name: "group by id", params: `{"GROUP": ["ACTIVE", "ID"], "ORDER": "ACTIVE"}`, wantSQL: " GROUP BY t.ACTIVE, t.ID ORDER BY t.ACTIVE ASC, t.ID ASC LIMIT 100", wantPage: 1, wantLimit: 100},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			params, err := ParseParams(decode(t, test.params), testFields, "SORT ASC")
+			if err != nil {
+				t.Fatalf("ParseParams(%s) error: %v", test.params, err)
+			}
+			if sql := params.SQL(); sql != test.wantSQL {
+				t.Errorf("ParseParams(%s).SQL() = %q, want %q", test.params, sql, test.wantSQL)
+			}
+			if params.Page != test.wantPage || params.Limit != test.wantLimit {
+				t.Errorf("ParseParams(%s) page %d limit %d, want page %d limit %d", test.params,
+					params.Page, params.Limit, test.wantPage, test.wantLimit)
+			}
+		})
+	}
+}
+
+func TestParseParamsErrors(t *testing.T) {
+	tests := []struct {
+		name    string
+		params  string
+		unknown bool
+	}{
+		{name: "zero limit", params: `{"LIMIT": 0}`},
+		{name: "negative limit", params: `{"LIMIT": -5}`},
+		{name: "limit over max", params: `{"LIMIT": 1001}`},
+		{name: "limit not a number", params: `{"LIMIT": "ten"}`},
+		{name: "fractional limit", params: `{"LIMIT": 10.5}`},
+		{name: "limit injection", params: `{"LIMIT": "10; DROP TABLE b_user"}`},
+		{name: "zero page", params: `{"PAGE": 0}`},
+		{name: "negative page", params: `{"PAGE": -1}`},
+		{name: "page not a number", params: `{"PAGE": "first"}`},
+		{name: "negative offset", params: `{"OFFSET": -1}`},
+		{name: "offset not a number", params: `{"OFFSET": [1]}`},
+		{name: "unknown order field", params: `{"ORDER": "PASSWORD"}`, unknown: true},
+		{name: "order injection", params: `{"ORDER": "(SELECT 1)"}`, unknown: true},
+		{name: "bad order direction", params: `{"ORDER": "NAME UP"}`},
+		{name: "order direction injection", params: `{"ORDER": "NAME DESC; DROP"}`},
+		{name: "too many order words", params: `{"ORDER": "NAME DESC NULLS"}`},
+		{name: "order not a string", params: `{"ORDER": 1}`},
+		{name: "order list with number", params: `{"ORDER": ["NAME", 1]}`},
+		{name: "unknown group field", params: `{"GROUP": ["PASSWORD"]}`, unknown: true},
+		{name: "group not a string", params: `{"GROUP": {"ID": 1}}`},
+		{name: "order not in group", params: `{"GROUP": "ACTIVE", "ORDER": "SORT"}`},
+		{name: "order by id not in group", params: `{"GROUP": "ACTIVE", "ORDER": "ACTIVE, ID DESC"}`},
+		{name: "unknown param", params: `{"SELECT": ["ID"]}`},
+		{name: "lower case param", params: `{"limit": 10}`},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			_, err := ParseParams(decode(t, test.params), testFields, "SORT ASC")
+			if err == nil {
+				t.Fatalf("ParseParams(%s) want error", test.params)
+			}
+			if _, isUnknown := err.(*UnknownFieldError); isUnknown != test.unknown {
+				t.Errorf("ParseParams(%s) error %T %v, unknown field error = %v", test.params, err, err, test.unknown)
+			}
+		})
+	}
+}
+
+func TestGroupColumns(t *testing.T) {
+	tests := []struct {
+		name    string
+		group   string
+		selects []string
+		expand  []string
+		want    string
+		wantErr bool
+	}{
+		{name: "grouped fields", group: `["IBLOCK_ID", "ACTIVE"]`, selects: []string{"ACTIVE", "IBLOCK_ID"}, want: "t.IBLOCK_ID, t.ACTIVE, MIN(`t`.ID) AS ID"},
+		{name: "part of group", group: `["IBLOCK_ID", "ACTIVE"]`, selects: []string{"ACTIVE"}, want: "t.IBLOCK_ID, t.ACTIVE, MIN(`t`.ID) AS ID"},
+		{name: "id selected", group: `"ACTIVE"`, selects: []string{"ID", "ACTIVE"}, want: "t.ACTIVE, MIN(`t`.ID) AS ID"},
+		{name: "grouped by id", group: `["ACTIVE", "ID"]`, selects: []string{"ACTIVE"}, want: "t.ACTIVE, t.ID"},
+		{name: "empty expand", group: `"ACTIVE"`, selects: []string{"ACTIVE"}, expand: []string{}, want: "t.ACTIVE, MIN(`t`.ID) AS ID"},
+		{name: "full select", group: `"ACTIVE"`, wantErr: true},
+		{name: "field not in group", group: `"ACTIVE"`, selects: []string{"ACTIVE", "NAME"}, wantErr: true},
+		{name: "expand", group: `"ACTIVE"`, selects: []string{"ACTIVE"}, expand: []string{"meta"}, wantErr: true},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			params, err := ParseParams(decode(t, `{"GROUP": `+test.group+`}`), testFields, "SORT ASC")
+			if err != nil {
+				t.Fatal(err)
+			}
+			columns, err := params.GroupColumns(Selection{Fields: test.selects}, test.expand)
+			if test.wantErr {
+				if err == nil {
+					t.Fatalf("GroupColumns(%v, %v) = %q, want error", test.selects, test.expand, columns)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("GroupColumns(%v, %v) error: %v", test.selects, test.expand, err)
+			}
+			if columns != test.want {
+				t.Errorf("GroupColumns(%v, %v) = %q, want %q", test.selects, test.expand, columns, test.want)
+			}
+		})
+	}
+}
+
+func TestParseStreamParams(t *testing.T) {
+	params, err := ParseStreamParams(decode(t, `{}`), testFields, "ID ASC")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if sql := params.SQL(); sql != " ORDER BY t.ID ASC" {
+		t.Errorf("stream without LIMIT SQL() = %q", sql)
+	}
+
+	params, err = ParseStreamParams(decode(t, `{"LIMIT": 5000}`), testFields, "ID ASC")
+	if err != nil {
+		t.Fatalf("stream LIMIT over MaxLimit error: %v", err)
+	}
+	if params.Limit != 5000 {
+		t.Errorf("stream limit = %d, want 5000", params.Limit)
+	}
+
+	params, err = ParseStreamParams(decode(t, `{"OFFSET": 20}`), testFields, "ID ASC")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if sql := params.SQL(); sql != " ORDER BY t.ID ASC LIMIT 18446744073709551615 OFFSET 20" {
+		t.Errorf("stream with OFFSET only SQL() = %q", sql)
+	}
+
+	if _, err = ParseStreamParams(decode(t, `{"PAGE": 2}`), testFields, "ID ASC"); err == nil {
+		t.Error("stream PAGE without LIMIT want error")
+	}
+
+	if _, err = ParseStreamParams(decode(t, `{"GROUP": "SORT"}`), testFields, "ID ASC"); err == nil {
+		t.Error("stream GROUP want error")
+	}
+}
+
+func TestParseOrder(t *testing.T) {
+	tests := []struct {
+		order []string
+		want  []Sort
+	}{
+		{
+			order: []string{"SORT"},
+			want:  []Sort{{"SORT", "t.SORT", "ASC"}, {"ID", "t.ID", "ASC"}},
+		},
+		{
+			order: []string{" name  desc ,, sort asc "},
+			want:  []Sort{{"NAME", "t.NAME", "DESC"}, {"SORT", "t.SORT", "ASC"}, {"ID", "t.ID", "ASC"}},
+		},
+		{
+			order: []string{"ID DESC", "NAME"},
+			want:  []Sort{{"ID", "t.ID", "DESC"}, {"NAME", "t.NAME", "ASC"}},
+		},
+		{
+			order: []string{""},
+			want:  []Sort{{"ID", "t.ID", "ASC"}},
+		},
+	}
+
+	for _, test := range tests {
+		sorts, err := parseOrder(test.order, testFields, nil)
+		if err != nil {
+			t.Errorf("parseOrder(%q) error: %v", test.order, err)
+			continue
+		}
+		if !reflect.DeepEqual(sorts, test.want) {
+			t.Errorf("parseOrder(%q) = %v, want %v", test.order, sorts, test.want)
+		}
+	}
+
+	// без ID в белом списке ничего не добавляется
+	sorts, err := parseOrder([]string{"CODE"}, map[string]string{"CODE": "t.CODE"}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(sorts, []Sort{{"CODE", "t.CODE", "ASC"}}) {
+		t.Errorf("parseOrder without ID field = %v", sorts)
+	}
+}
+
+func TestPagination(t *testing.T) {
+	params := Params{Limit: 10, Offset: 20, Page: 3}
+
+	pagination := params.Pagination(35)
+	if pagination.NextPage == nil || *pagination.NextPage != 4 || pagination.NextOffset == nil || *pagination.NextOffset != 30 {
+		t.Errorf("Pagination(35) next = %v %v, want 4 30", pagination.NextPage, pagination.NextOffset)
+	}
+
+	pagination = params.Pagination(30)
+	if pagination.NextPage != nil || pagination.NextOffset != nil {
+		t.Errorf("Pagination(30) on last page has next %v %v", pagination.NextPage, pagination.NextOffset)
+	}
+}
@@ -0,0 +1,154 @@
+package filter
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"net/url"
+	"reflect"
+	"strings"
+)
+
+// Selection - выбранные поля (select) и подгружаемые связи (expand) записи
+type Selection struct {
+	// Fields - выбранные колонки, пусто - все
+	Fields []string
+	expand map[string]bool
+}
+
+// ParseSelection - select по списку колонок записи, expand по списку допустимых связей.
+// expand == nil (не передан) - связи defaultExpand, пустой массив - без связей
+func ParseSelection(selectFields []string, expand []string, columns []string, expansions []string, defaultExpand []string) (selection Selection, errorMessage error) {
+	allowed := make(map[string]bool, len(columns))
+	for _, column := range columns {
+		allowed[strings.TrimSpace(column)] = true
+	}
+
+	for _, field := range selectFields {
+		field = strings.ToUpper(strings.TrimSpace(field))
+		if !allowed[field] {
+			errorMessage = errors.New("unknown select field " + field + ", allowed: " + strings.Join(trimmed(columns), ", "))
+			return
+		}
+		selection.Fields = append(selection.Fields, field)
+	}
+
+	if expand == nil {
+		expand = defaultExpand
+	}
+	selection.expand = make(map[string]bool, len(expand))
+	for _, relation := range expand {
+		relation = strings.ToLower(strings.TrimSpace(relation))
+		if !contains(expansions, relation) {
+			errorMessage = errors.New("unknown expand " + relation + ", allowed: " + strings.Join(expansions, ", "))
+			return
+		}
+		selection.expand[relation] = true
+	}
+
+	return
+}
+
+// QuerySelection - select и expand из строки запроса через запятую (?select=ID,NAME&expand=meta)
+func QuerySelection(values url.Values) (selectFields []string, expand []string) {
+	if value := values.Get("select"); value != "" {
+		selectFields = strings.Split(value, ",")
+	}
+	if _, found := values["expand"]; found {
+		expand = []string{}
+		if value := values.Get("expand"); value != "" {
+			expand = strings.Split(value, ",")
+		}
+	}
+
+	return
+}
+
+// Expands - связь запрошена
+func (selection Selection) Expands(relation string) bool {
+	return selection.expand[relation]
+}
+
+// Selected - колонка попадет в ответ
+func (selection Selection) Selected(column string) bool {
+	return len(selection.Fields) == 0 || column == "ID" || contains(selection.Fields, column)
+}
+
+// Columns - список колонок для SELECT с алиасом t, required выбираются всегда (нужны для связей)
+func (selection Selection) Columns(columns []string, required ...string) string {
+	var selected []string
+	for _, column := range trimmed(columns) {
+		if selection.Selected(column) || contains(required, column) {
+			selected = append(selected, "`t`."+column)
+		}
+	}
+
+	return strings.Join(selected, ", ")
+}
+
+// Marshal - json записи только с выбранными колонками (по тегу db), связи без тега db
+// попадают в ответ, если подгружены
+func (selection Selection) Marshal(record interface{}) (result []byte, errorMessage error) {
+	value := reflect.ValueOf(record)
+	for value.Kind() == reflect.Ptr {
+		value = value.Elem()
+	}
+	recordType := value.Type()
+
+	var buffer bytes.Buffer
+	buffer.WriteByte('{')
+	written := 0
+	for i := 0; i < recordType.NumField(); i++ {
+		field := recordType.Field(i)
+		name := strings.Split(field.Tag.Get("json"), ",")[0]
+		if field.PkgPath != "" || name == "-" || name == "" {
+			continue
+		}
+
+		column := field.Tag.Get("db")
+		if column != "" && !selection.Selected(column) {
+			continue
+		}
+		if column == "" && value.Field(i).IsZero() {
+			continue
+		}
+
+		data, err := json.Marshal(value.Field(i).Interface())
+		if err != nil {
+			errorMessage = err
+			return
+		}
+
+		if written > 0 {
+			buffer.WriteByte(',')
+		}
+		key, _ := json.Marshal(name)
+		buffer.Write(key)
+		buffer.WriteByte(':')
+		buffer.Write(data)
+		written++
+	}
+	buffer.WriteByte('}')
+
+	result = buffer.Bytes()
+
+	return
+}
+
+func trimmed(columns []string) (result []string) {
+	for _, column := range columns {
+		result = append(result, strings.TrimSpace(column))
+	}
+
+	return
+}
+
+func contains(list []string, value string) bool {
+	for _, item := range list {
+		if item == value {
+			return true
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,35 @@
+package iblock
+
+import (
+	"github.com/jmoiron/sqlx"
+)
+
+// Enum - вариант значения свойства типа список
+type Enum struct {
+	ID    uint64 `db:"ID" json:"id"`
+	Value string `db:"VALUE" json:"value"`
+	XMLID string `db:"XML_ID" json:"xml_id"`
+	Sort  int    `db:"SORT" json:"-"`
+}
+
+// FindEnums - варианты списков по ID
+func FindEnums(conn *sqlx.DB, ids []uint64) (enums map[uint64]*Enum, errorMessage error) {
+	enums = make(map[uint64]*Enum, len(ids))
+	if len(ids) == 0 {
+		return
+	}
+
+	query, args, err := sqlx.In("SELECT ID, VALUE, XML_ID, SORT FROM b_iblock_property_enum WHERE ID IN (?)", ids)
+	if err != nil {
+		errorMessage = err
+		return
+	}
+
+	var rows []*Enum
+	errorMessage = conn.Select(&rows, query, args...)
+	for _, enum := range rows {
+		enums[enum.ID] = enum
+	}
+
+	return
+}
@@ -0,0 +1,115 @@
+package iblock
+
+import (
+	"encoding/json"
+	"strings"
+
+	"github.com/jmoiron/sqlx"
+
+	"../database"
+)
+
+// File - файл из b_file
+type File struct {
+	ID           uint64              `db:"ID" json:"id"`
+	Src          string              `json:"src"`
+	SubDir       string              `db:"SUBDIR" json:"subdir"`
+	FileName     string              `db:"FILE_NAME" json:"file_name"`
+	OriginalName database.NullString `db:"ORIGINAL_NAME" json:"original_name"`
+	ContentType  database.NullString `db:"CONTENT_TYPE" json:"content_type"`
+	FileSize     database.NullInt64  `db:"FILE_SIZE" json:"file_size"`
+	Width        database.NullInt64  `db:"WIDTH" json:"width"`
+	Height       database.NullInt64  `db:"HEIGHT" json:"height"`
+	Description  database.NullString `db:"DESCRIPTION" json:"description"`
+}
+
+// Picture - картинка элемента или раздела: из базы читается ID файла, в ответ отдается файл из b_file,
+// null - картинки нет или файла нет в b_file
+type Picture struct {
+	FileID database.NullInt64
+	File   *File
+}
+
+// Scan - ID файла из колонки PICTURE
+func (picture *Picture) Scan(value interface{}) error {
+	return picture.FileID.Scan(value)
+}
+
+// MarshalJSON - файл картинки
+func (picture Picture) MarshalJSON() ([]byte, error) {
+	return json.Marshal(picture.File)
+}
+
+// UnmarshalJSON - файл картинки из ответа сервиса
+func (picture *Picture) UnmarshalJSON(data []byte) error {
+	err := json.Unmarshal(data, &picture.File)
+	if err != nil {
+		return err
+	}
+
+	picture.FileID.Valid = picture.File != nil
+	picture.FileID.Int64 = 0
+	if picture.File != nil {
+		picture.FileID.Int64 = int64(picture.File.ID)
+	}
+
+	return nil
+}
+
+// FindFiles - файлы по ID, src - uploadURL (/upload/ или адрес сервера с файлами) + SUBDIR/FILE_NAME
+func FindFiles(conn *sqlx.DB, uploadURL string, ids []uint64) (files map[uint64]*File, errorMessage error) {
+	files = make(map[uint64]*File, len(ids))
+	if len(ids) == 0 {
+		return
+	}
+
+	query, args, err := sqlx.In("SELECT ID, SUBDIR, FILE_NAME, ORIGINAL_NAME, CONTENT_TYPE, FILE_SIZE, WIDTH, HEIGHT, DESCRIPTION"+
+		" FROM b_file WHERE ID IN (?)", ids)
+	if err != nil {
+		errorMessage = err
+		return
+	}
+
+	var rows []*File
+	errorMessage = conn.Select(&rows, query, args...)
+	for _, file := range rows {
+		file.Src = fileSrc(uploadURL, file.SubDir, file.FileName)
+		files[file.ID] = file
+	}
+
+	return
+}
+
+// ResolvePictures - файлы для пачки картинок одним запросом
+func ResolvePictures(conn *sqlx.DB, uploadURL string, pictures []*Picture) (errorMessage error) {
+	var ids []uint64
+	for _, picture := range pictures {
+		if picture.FileID.Valid {
+			ids = append(ids, uint64(picture.FileID.Int64))
+		}
+	}
+	if len(ids) == 0 {
+		return
+	}
+
+	files, errorMessage := FindFiles(conn, uploadURL, ids)
+	if errorMessage != nil {
+		return
+	}
+	for _, picture := range pictures {
+		if picture.FileID.Valid {
+			picture.File = files[uint64(picture.FileID.Int64)]
+		}
+	}
+
+	return
+}
+
+func fileSrc(uploadURL string, subDir string, fileName string) string {
+	src := strings.TrimSuffix(uploadURL, "/") + "/"
+	if subDir = strings.Trim(subDir, "/"); subDir != "" {
+		src += subDir + "/"
+	}
+
+	return src + fileName
+}
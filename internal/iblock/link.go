@@ -0,0 +1,53 @@
+package iblock
+
+import (
+	"github.com/jmoiron/sqlx"
+)
+
+// LinkedElements - элементы, у которых свойство-привязка (E) ссылается на один из values: ID из values -> ID элементов.
+// Значения читаются из таблицы, где их хранит инфоблок свойства
+func LinkedElements(conn *sqlx.DB, property *Property, values []uint64) (linked map[uint64][]uint64, errorMessage error) {
+	linked = make(map[uint64][]uint64)
+	if len(values) == 0 {
+		return
+	}
+
+	var query string
+	var args []interface{}
+	var err error
+	if property.InSingleTable() {
+		query, args, err = sqlx.In("SELECT CAST("+property.Column()+" AS UNSIGNED), IBLOCK_ELEMENT_ID"+
+			" FROM "+property.SingleTable()+
+			" WHERE "+property.Column()+" IN (?)"+
+			" ORDER BY IBLOCK_ELEMENT_ID", values)
+	} else {
+		query, args, err = sqlx.In("SELECT CAST(VALUE_NUM AS UNSIGNED), IBLOCK_ELEMENT_ID"+
+			" FROM "+property.ValueTable()+
+			" WHERE IBLOCK_PROPERTY_ID = ? AND VALUE_NUM IN (?)"+
+			" ORDER BY IBLOCK_ELEMENT_ID", property.ID, values)
+	}
+	if err != nil {
+		errorMessage = err
+		return
+	}
+
+	rows, err := conn.Queryx(query, args...)
+	if err != nil {
+		errorMessage = err
+		return
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var value, elementID uint64
+		err = rows.Scan(&value, &elementID)
+		if err != nil {
+			errorMessage = err
+			return
+		}
+		linked[value] = append(linked[value], elementID)
+	}
+	errorMessage = rows.Err()
+
+	return
+}
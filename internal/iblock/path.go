@@ -0,0 +1,58 @@
+package iblock
+
+import (
+	"github.com/jmoiron/sqlx"
+
+	"../database"
+)
+
+// PathSection - раздел в цепочке от верхнего уровня (хлебные крошки)
+type PathSection struct {
+	ID             uint64              `db:"ID" json:"id"`
+	Code           database.NullString `db:"CODE" json:"code"`
+	XMLID          database.NullString `db:"XML_ID" json:"xml_id"`
+	Name           string              `db:"NAME" json:"name"`
+	IblockID       uint64              `db:"IBLOCK_ID" json:"iblock_id"`
+	DepthLevel     uint64              `db:"DEPTH_LEVEL" json:"depth_level"`
+	SectionPageURL string              `json:"section_page_url"`
+}
+
+type pathRow struct {
+	SectionID uint64 `db:"SECTION_ID"`
+	PathSection
+}
+
+// SectionPaths - цепочки разделов от верхнего уровня до каждого раздела включительно, одним запросом
+// по LEFT_MARGIN/RIGHT_MARGIN. Раздела нет - нет и цепочки
+func SectionPaths(conn sqlx.Queryer, sectionIDs []uint64) (paths map[uint64][]PathSection, errorMessage error) {
+	paths = make(map[uint64][]PathSection, len(sectionIDs))
+	if len(sectionIDs) == 0 {
+		return
+	}
+
+	query, args, err := sqlx.In("SELECT s.ID AS SECTION_ID, p.ID, p.CODE, p.XML_ID, p.NAME, p.IBLOCK_ID, p.DEPTH_LEVEL"+
+		" FROM b_iblock_section s"+
+		" INNER JOIN b_iblock_section p ON p.IBLOCK_ID = s.IBLOCK_ID"+
+		" AND p.LEFT_MARGIN <= s.LEFT_MARGIN AND p.RIGHT_MARGIN >= s.RIGHT_MARGIN"+
+		" WHERE s.ID IN (?)"+
+		" ORDER BY s.ID, p.LEFT_MARGIN", sectionIDs)
+	if err != nil {
+		errorMessage = err
+		return
+	}
+
+	var rows []pathRow
+	errorMessage = sqlx.Select(conn, &rows, query, args...)
+	for _, row := range rows {
+		paths[row.SectionID] = append(paths[row.SectionID], row.PathSection)
+	}
+
+	return
+}
+
+// SetURLs - SECTION_PAGE_URL каждого раздела цепочки по шаблону инфоблока
+func (iblock *Iblock) SetURLs(path []PathSection) {
+	for index := range path {
+		path[index].SectionPageURL = iblock.SectionURL(path[:index+1])
+	}
+}
@@ -0,0 +1,121 @@
+// Package iblock - описание свойств инфоблоков и мест их хранения
+package iblock
+
+import (
+	"strconv"
+
+	"github.com/jmoiron/sqlx"
+
+	"../database"
+)
+
+// Property - свойство инфоблока вместе с версией хранения инфоблока
+type Property struct {
+	ID              uint64              `db:"ID" json:"id"`
+	IblockID        uint64              `db:"IBLOCK_ID" json:"iblock_id"`
+	Code            database.NullString `db:"CODE" json:"code"`
+	Name            string              `db:"NAME" json:"name"`
+	Type            string              `db:"PROPERTY_TYPE" json:"type"`
+	UserType        database.NullString `db:"USER_TYPE" json:"user_type"`
+	Multiple        database.Bool       `db:"MULTIPLE" json:"multiple"`
+	WithDescription database.Bool       `db:"WITH_DESCRIPTION" json:"with_description"`
+	Version         int                 `db:"VERSION" json:"-"`
+}
+
+const propertySelect = "SELECT p.ID, p.IBLOCK_ID, p.CODE, p.NAME, p.PROPERTY_TYPE, p.USER_TYPE, p.MULTIPLE, p.WITH_DESCRIPTION, b.VERSION" +
+	" FROM b_iblock_property p" +
+	" INNER JOIN b_iblock b ON b.ID = p.IBLOCK_ID"
+
+// FindProperties - свойства по ID или символьному коду, iblockID = 0 - во всех инфоблоках
+func FindProperties(conn *sqlx.DB, key string, iblockID uint64) (properties []Property, errorMessage error) {
+	query := propertySelect
+	var args []interface{}
+
+	if id, err := strconv.ParseUint(key, 10, 64); err == nil {
+		query += " WHERE p.ID = ?"
+		args = append(args, id)
+	} else {
+		query += " WHERE p.CODE = ?"
+		args = append(args, key)
+	}
+	if iblockID > 0 {
+		query += " AND p.IBLOCK_ID = ?"
+		args = append(args, iblockID)
+	}
+
+	errorMessage = conn.Select(&properties, query, args...)
+
+	return
+}
+
+// Separate - значения хранятся в отдельных таблицах инфоблока (VERSION = 2)
+func (property *Property) Separate() bool {
+	return property.Version == 2
+}
+
+// SingleTable - таблица одиночных значений инфоблока с отдельным хранением
+func (property *Property) SingleTable() string {
+	return "b_iblock_element_prop_s" + strconv.FormatUint(property.IblockID, 10)
+}
+
+// MultipleTable - таблица множественных значений инфоблока с отдельным хранением
+func (property *Property) MultipleTable() string {
+	return "b_iblock_element_prop_m" + strconv.FormatUint(property.IblockID, 10)
+}
+
+// Column - колонка свойства в таблице одиночных значений
+func (property *Property) Column() string {
+	return "PROPERTY_" + strconv.FormatUint(property.ID, 10)
+}
+
+// HasDescription - у значений есть описание, при отдельном хранении - колонка DESCRIPTION_<ID>
+func (property *Property) HasDescription() bool {
+	return property.WithDescription.String == "Y"
+}
+
+// DescriptionColumn - колонка описания в таблице одиночных значений
+func (property *Property) DescriptionColumn() string {
+	return "DESCRIPTION_" + strconv.FormatUint(property.ID, 10)
+}
+
+// InSingleTable - значение лежит колонкой в b_iblock_element_prop_s<IBLOCK_ID>
+func (property *Property) InSingleTable() bool {
+	return property.Separate() && !property.Multiple.IsTrue()
+}
+
+// ValueTable - таблица со строками значений (IBLOCK_ELEMENT_ID, IBLOCK_PROPERTY_ID, VALUE, VALUE_ENUM, VALUE_NUM)
+func (property *Property) ValueTable() string {
+	if property.Separate() {
+		return property.MultipleTable()
+	}
+
+	return "b_iblock_element_property"
+}
+
+// IblockProperties - активные свойства инфоблоков в порядке сортировки
+func IblockProperties(conn *sqlx.DB, iblockIDs []uint64) (properties []Property, errorMessage error) {
+	if len(iblockIDs) == 0 {
+		return
+	}
+
+	query, args, err := sqlx.In(propertySelect+
+		" WHERE p.IBLOCK_ID IN (?) AND p.ACTIVE = 'Y'"+
+		" ORDER BY p.SORT, p.ID", iblockIDs)
+	if err != nil {
+		errorMessage = err
+		return
+	}
+
+	errorMessage = conn.Select(&properties, query, args...)
+
+	return
+}
+
+// Key - ключ свойства в ответе: символьный код, без кода - ID
+func (property *Property) Key() string {
+	if property.Code.Valid && property.Code.String != "" {
+		return property.Code.String
+	}
+
+	return strconv.FormatUint(property.ID, 10)
+}
@@ -0,0 +1,210 @@
+package iblock
+
+import (
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/jmoiron/sqlx"
+
+	"../database"
+)
+
+// Iblock - инфоблок с шаблонами ссылок и папкой сайта для #SITE_DIR#
+type Iblock struct {
+	ID             uint64              `db:"ID" json:"id"`
+	Code           database.NullString `db:"CODE" json:"code"`
+	TypeID         string              `db:"IBLOCK_TYPE_ID" json:"iblock_type_id"`
+	XMLID          database.NullString `db:"XML_ID" json:"xml_id"`
+	DetailPageURL  database.NullString `db:"DETAIL_PAGE_URL" json:"detail_page_url"`
+	SectionPageURL database.NullString `db:"SECTION_PAGE_URL" json:"section_page_url"`
+	ListPageURL    database.NullString `db:"LIST_PAGE_URL" json:"list_page_url"`
+	SiteDir        database.NullString `db:"SITE_DIR" json:"-"`
+	ServerName     database.NullString `db:"SERVER_NAME" json:"-"`
+}
+
+// URLElement - поля элемента для шаблона DETAIL_PAGE_URL, SectionID - основной раздел (0 - без раздела)
+type URLElement struct {
+	ID        uint64
+	IblockID  uint64
+	SectionID uint64
+	Code      string
+	XMLID     string
+}
+
+// PageURLs - ссылки на элемент или раздел и на список инфоблока
+type PageURLs struct {
+	Page string
+	List string
+}
+
+// slashes - повторные слэши после подстановки пустых значений, кроме http://
+var slashes = regexp.MustCompile(`(^|[^:])//+`)
+
+// FindIblocks - инфоблоки по ID, #SITE_DIR# и #SERVER_NAME# - сайта siteID из b_lang. conn - пул или транзакция
+func FindIblocks(conn sqlx.Queryer, siteID string, ids []uint64) (iblocks map[uint64]*Iblock, errorMessage error) {
+	iblocks = make(map[uint64]*Iblock, len(ids))
+	if len(ids) == 0 {
+		return
+	}
+
+	query, args, err := sqlx.In("SELECT b.ID, b.CODE, b.IBLOCK_TYPE_ID, b.XML_ID,"+
+		" b.DETAIL_PAGE_URL, b.SECTION_PAGE_URL, b.LIST_PAGE_URL, l.DIR AS SITE_DIR, l.SERVER_NAME"+
+		" FROM b_iblock b"+
+		" LEFT JOIN b_lang l ON l.LID = ?"+
+		" WHERE b.ID IN (?)", siteID, ids)
+	if err != nil {
+		errorMessage = err
+		return
+	}
+
+	var rows []*Iblock
+	errorMessage = sqlx.Select(conn, &rows, query, args...)
+	for _, iblock := range rows {
+		iblocks[iblock.ID] = iblock
+	}
+
+	return
+}
+
+// ElementURLs - DETAIL_PAGE_URL и LIST_PAGE_URL для пачки элементов: инфоблоки и цепочки разделов
+// для #SECTION_CODE_PATH# читаются одним запросом на всю пачку
+func ElementURLs(conn sqlx.Queryer, siteID string, elements []URLElement) (urls map[uint64]PageURLs, errorMessage error) {
+	urls = make(map[uint64]PageURLs, len(elements))
+	var iblockIDs, sectionIDs []uint64
+	for _, element := range elements {
+		iblockIDs = append(iblockIDs, element.IblockID)
+		if element.SectionID > 0 {
+			sectionIDs = append(sectionIDs, element.SectionID)
+		}
+	}
+
+	iblocks, errorMessage := FindIblocks(conn, siteID, iblockIDs)
+	if errorMessage != nil {
+		return
+	}
+	paths, errorMessage := SectionPaths(conn, sectionIDs)
+	if errorMessage != nil {
+		return
+	}
+
+	for _, element := range elements {
+		if iblock, found := iblocks[element.IblockID]; found {
+			urls[element.ID] = PageURLs{
+				Page: iblock.DetailURL(element, paths[element.SectionID]),
+				List: iblock.ListURL(),
+			}
+		}
+	}
+
+	return
+}
+
+// SectionURLs - SECTION_PAGE_URL и LIST_PAGE_URL для пачки разделов
+func SectionURLs(conn sqlx.Queryer, siteID string, sectionIDs []uint64) (urls map[uint64]PageURLs, errorMessage error) {
+	urls = make(map[uint64]PageURLs, len(sectionIDs))
+	paths, errorMessage := SectionPaths(conn, sectionIDs)
+	if errorMessage != nil {
+		return
+	}
+
+	var iblockIDs []uint64
+	for _, path := range paths {
+		iblockIDs = append(iblockIDs, path[0].IblockID)
+	}
+	iblocks, errorMessage := FindIblocks(conn, siteID, iblockIDs)
+	if errorMessage != nil {
+		return
+	}
+
+	for sectionID, path := range paths {
+		if iblock, found := iblocks[path[0].IblockID]; found {
+			urls[sectionID] = PageURLs{Page: iblock.SectionURL(path), List: iblock.ListURL()}
+		}
+	}
+
+	return
+}
+
+// ListURL - LIST_PAGE_URL инфоблока
+func (iblock *Iblock) ListURL() string {
+	return Render(iblock.ListPageURL.String, iblock.values())
+}
+
+// SectionURL - SECTION_PAGE_URL раздела, path - цепочка от верхнего уровня до раздела включительно
+func (iblock *Iblock) SectionURL(path []PathSection) string {
+	values := iblock.values()
+	if len(path) > 0 {
+		section := path[len(path)-1]
+		setSection(values, path)
+		values["ID"] = strconv.FormatUint(section.ID, 10)
+		values["CODE"] = section.Code.String
+		values["EXTERNAL_ID"] = section.XMLID.String
+	}
+
+	return Render(iblock.SectionPageURL.String, values)
+}
+
+// DetailURL - DETAIL_PAGE_URL элемента, path - цепочка до основного раздела элемента
+func (iblock *Iblock) DetailURL(element URLElement, path []PathSection) string {
+	values := iblock.values()
+	setSection(values, path)
+	values["ID"] = strconv.FormatUint(element.ID, 10)
+	values["ELEMENT_ID"] = values["ID"]
+	values["CODE"] = element.Code
+	values["ELEMENT_CODE"] = element.Code
+	values["EXTERNAL_ID"] = element.XMLID
+
+	return Render(iblock.DetailPageURL.String, values)
+}
+
+func (iblock *Iblock) values() map[string]string {
+	siteDir := iblock.SiteDir.String
+	if siteDir == "" {
+		siteDir = "/"
+	}
+
+	return map[string]string{
+		"SITE_DIR":           siteDir,
+		"LANG":               siteDir,
+		"SERVER_NAME":        iblock.ServerName.String,
+		"IBLOCK_ID":          strconv.FormatUint(iblock.ID, 10),
+		"IBLOCK_CODE":        iblock.Code.String,
+		"IBLOCK_TYPE_ID":     iblock.TypeID,
+		"IBLOCK_EXTERNAL_ID": iblock.XMLID.String,
+		"SECTION_ID":         "",
+		"SECTION_CODE":       "",
+		"SECTION_CODE_PATH":  "",
+	}
+}
+
+// setSection - #SECTION_ID#, #SECTION_CODE# и #SECTION_CODE_PATH# (коды через / от верхнего уровня)
+func setSection(values map[string]string, path []PathSection) {
+	if len(path) == 0 {
+		return
+	}
+
+	codes := make([]string, 0, len(path))
+	for _, section := range path {
+		codes = append(codes, section.Code.String)
+	}
+	section := path[len(path)-1]
+	values["SECTION_ID"] = strconv.FormatUint(section.ID, 10)
+	values["SECTION_CODE"] = section.Code.String
+	values["SECTION_CODE_PATH"] = strings.Join(codes, "/")
+}
+
+// Render - подставляем #KEY# из values, неизвестные ключи остаются как есть, повторные слэши схлопываются
+func Render(template string, values map[string]string) string {
+	if template == "" {
+		return ""
+	}
+
+	pairs := make([]string, 0, len(values)*2)
+	for key, value := range values {
+		pairs = append(pairs, "#"+key+"#", value)
+	}
+	result := strings.NewReplacer(pairs...).Replace(template)
+
+	return slashes.ReplaceAllString(result, "$1/")
+}
@@ -0,0 +1,164 @@
+package iblock
+
+import (
+	"database/sql"
+	"testing"
+
+	"../database"
+)
+
+func text(value string) database.NullString {
+	return database.NullString{NullString: sql.NullString{String: value, Valid: value != ""}}
+}
+
+// testPath - цепочка mebel/shkafy, как ее отдает SectionPaths
+var testPath = []PathSection{
+	{ID: 3, Code: text("mebel"), IblockID: 2, DepthLevel: 1},
+	{ID: 7, Code: text("shkafy"), XMLID: text("ext-7"), IblockID: 2, DepthLevel: 2},
+}
+
+func TestRender(t *testing.T) {
+	values := map[string]string{"SITE_DIR": "/", "CODE": "shkaf-1", "SECTION_CODE_PATH": "", "EMPTY": ""}
+	tests := []struct {
+		template string
+		want     string
+	}{
+		{"", ""},
+		{"#SITE_DIR#/catalog/#CODE#/", "/catalog/shkaf-1/"},
+		{"#SITE_DIR#/catalog/#SECTION_CODE_PATH#/#CODE#/", "/catalog/shkaf-1/"},
+		{"/catalog/#EMPTY#/#EMPTY#//#CODE#", "/catalog/shkaf-1"},
+		{"http://example.com/#CODE#/", "http://example.com/shkaf-1/"},
+		{"https://#EMPTY#example.com//#CODE#/", "https://example.com/shkaf-1/"},
+		{"#SITE_DIR#/#UNKNOWN#/#CODE#/", "/#UNKNOWN#/shkaf-1/"},
+		{"/detail.php?ID=#ID#&CODE=#CODE#", "/detail.php?ID=#ID#&CODE=shkaf-1"},
+		{"/#CODE", "/#CODE"},
+	}
+
+	for _, test := range tests {
+		if got := Render(test.template, values); got != test.want {
+			t.Errorf("Render(%q) = %q, want %q", test.template, got, test.want)
+		}
+	}
+}
+
+func TestDetailURL(t *testing.T) {
+	tests := []struct {
+		name     string
+		siteDir  string
+		template string
+		path     []PathSection
+		want     string
+	}{
+		{
+			name:     "section code path",
+			template: "#SITE_DIR#/catalog/#SECTION_CODE_PATH#/#ELEMENT_CODE#/",
+			path:     testPath,
+			want:     "/catalog/mebel/shkafy/shkaf-1/",
+		},
+		{
+			name:     "without section",
+			template: "#SITE_DIR#/catalog/#SECTION_CODE_PATH#/#ELEMENT_CODE#/",
+			want:     "/catalog/shkaf-1/",
+		},
+		{
+			name:     "site dir",
+			siteDir:  "/en/",
+			template: "#SITE_DIR#/catalog/#SECTION_CODE#/#CODE#/",
+			path:     testPath,
+			want:     "/en/catalog/shkafy/shkaf-1/",
+		},
+		{
+			name:     "ids",
+			template: "/#IBLOCK_TYPE_ID#/#IBLOCK_CODE#/#IBLOCK_ID#/#SECTION_ID#/#ELEMENT_ID#/#ID#/#EXTERNAL_ID#/",
+			path:     testPath,
+			want:     "/catalog/furniture/2/7/15/15/ext-15/",
+		},
+		{
+			name:     "server name",
+			template: "http://#SERVER_NAME##SITE_DIR#catalog/#CODE#/",
+			want:     "http://shop.example.com/catalog/shkaf-1/",
+		},
+		{
+			name:     "unknown key",
+			template: "#SITE_DIR#/catalog/#PROPERTY_BRAND#/#CODE#/",
+			want:     "/catalog/#PROPERTY_BRAND#/shkaf-1/",
+		},
+		{
+			name: "empty template",
+			path: testPath,
+			want: "",
+		},
+	}
+
+	element := URLElement{ID: 15, IblockID: 2, SectionID: 7, Code: "shkaf-1", XMLID: "ext-15"}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			iblock := Iblock{
+				ID:            2,
+				Code:          text("furniture"),
+				TypeID:        "catalog",
+				DetailPageURL: text(test.template),
+				SiteDir:       text(test.siteDir),
+				ServerName:    text("shop.example.com"),
+			}
+			if got := iblock.DetailURL(element, test.path); got != test.want {
+				t.Errorf("DetailURL(%q) = %q, want %q", test.template, got, test.want)
+			}
+		})
+	}
+}
+
+func TestSectionURL(t *testing.T) {
+	tests := []struct {
+		name     string
+		template string
+		path     []PathSection
+		want     string
+	}{
+		{
+			name:     "section code path",
+			template: "#SITE_DIR#/catalog/#SECTION_CODE_PATH#/",
+			path:     testPath,
+			want:     "/catalog/mebel/shkafy/",
+		},
+		{
+			name:     "top level section",
+			template: "#SITE_DIR#/catalog/#SECTION_CODE_PATH#/",
+			path:     testPath[:1],
+			want:     "/catalog/mebel/",
+		},
+		{
+			name:     "section keys",
+			template: "/#ID#/#CODE#/#SECTION_ID#/#SECTION_CODE#/#EXTERNAL_ID#/",
+			path:     testPath,
+			want:     "/7/shkafy/7/shkafy/ext-7/",
+		},
+		{
+			name:     "empty code",
+			template: "#SITE_DIR#/catalog/#CODE#/",
+			path:     []PathSection{{ID: 9, IblockID: 2}},
+			want:     "/catalog/",
+		},
+		{
+			name:     "no path",
+			template: "#SITE_DIR#/catalog/#SECTION_CODE_PATH#/#UNKNOWN#/",
+			want:     "/catalog/#UNKNOWN#/",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			iblock := Iblock{ID: 2, SectionPageURL: text(test.template)}
+			if got := iblock.SectionURL(test.path); got != test.want {
+				t.Errorf("SectionURL(%q) = %q, want %q", test.template, got, test.want)
+			}
+		})
+	}
+}
+
+func TestListURL(t *testing.T) {
+	iblock := Iblock{ID: 2, TypeID: "catalog", ListPageURL: text("#SITE_DIR#/#IBLOCK_TYPE_ID#/"), SiteDir: text("/ru")}
+	if got := iblock.ListURL(); got != "/ru/catalog/" {
+		t.Errorf("ListURL() = %q", got)
+	}
+}
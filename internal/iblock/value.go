@@ -0,0 +1,178 @@
+package iblock
+
+import (
+	"database/sql"
+	"strconv"
+	"strings"
+
+	"github.com/jmoiron/sqlx"
+)
+
+// Value - строка значения свойства элемента
+type Value struct {
+	ElementID   uint64          `db:"IBLOCK_ELEMENT_ID"`
+	PropertyID  uint64          `db:"IBLOCK_PROPERTY_ID"`
+	Value       sql.NullString  `db:"VALUE"`
+	ValueEnum   sql.NullInt64   `db:"VALUE_ENUM"`
+	ValueNum    sql.NullFloat64 `db:"VALUE_NUM"`
+	Description sql.NullString  `db:"DESCRIPTION"`
+}
+
+// ElementValues - строки значений свойств элементов, elementIDs - ID элементов по инфоблокам,
+// byIblock - читаемые свойства инфоблоков. Инфоблоки с общим хранением читаются из
+// b_iblock_element_property одним запросом, с отдельным (VERSION = 2) - из своих таблиц s<ID> и m<ID>
+func ElementValues(conn *sqlx.DB, byIblock map[uint64][]*Property, elementIDs map[uint64][]uint64) (values []Value, errorMessage error) {
+	var commonIDs []uint64
+	separateIDs := make(map[uint64][]uint64)
+	for iblockID, ids := range elementIDs {
+		properties := byIblock[iblockID]
+		if len(properties) == 0 {
+			continue
+		}
+		if properties[0].Separate() {
+			separateIDs[iblockID] = ids
+		} else {
+			commonIDs = append(commonIDs, ids...)
+		}
+	}
+
+	if len(commonIDs) > 0 {
+		values, errorMessage = multipleValues(conn, "b_iblock_element_property", commonIDs)
+		if errorMessage != nil {
+			return
+		}
+	}
+
+	for iblockID, elementIDs := range separateIDs {
+		var single []*Property
+		hasMultiple := false
+		for _, property := range byIblock[iblockID] {
+			if property.InSingleTable() {
+				single = append(single, property)
+			} else {
+				hasMultiple = true
+			}
+		}
+
+		if len(single) > 0 {
+			singleValues, err := singleValues(conn, single, elementIDs)
+			if err != nil {
+				errorMessage = err
+				return
+			}
+			values = append(values, singleValues...)
+		}
+		if hasMultiple {
+			multipleValues, err := multipleValues(conn, byIblock[iblockID][0].MultipleTable(), elementIDs)
+			if err != nil {
+				errorMessage = err
+				return
+			}
+			values = append(values, multipleValues...)
+		}
+	}
+
+	return
+}
+
+// multipleValues - значения строками из b_iblock_element_property или b_iblock_element_prop_m<ID>
+func multipleValues(conn *sqlx.DB, table string, elementIDs []uint64) (values []Value, errorMessage error) {
+	query, args, err := sqlx.In("SELECT IBLOCK_ELEMENT_ID, IBLOCK_PROPERTY_ID, VALUE, VALUE_ENUM, VALUE_NUM, DESCRIPTION"+
+		" FROM "+table+
+		" WHERE IBLOCK_ELEMENT_ID IN (?)"+
+		" ORDER BY ID", elementIDs)
+	if err != nil {
+		errorMessage = err
+		return
+	}
+
+	errorMessage = conn.Select(&values, query, args...)
+
+	return
+}
+
+// singleValues - одиночные значения колонками PROPERTY_<ID> из b_iblock_element_prop_s<ID>
+func singleValues(conn *sqlx.DB, properties []*Property, elementIDs []uint64) (values []Value, errorMessage error) {
+	columns := []string{"IBLOCK_ELEMENT_ID"}
+	for _, property := range properties {
+		columns = append(columns, property.Column())
+		if property.HasDescription() {
+			columns = append(columns, property.DescriptionColumn())
+		}
+	}
+
+	query, args, err := sqlx.In("SELECT "+strings.Join(columns, ", ")+
+		" FROM "+properties[0].SingleTable()+
+		" WHERE IBLOCK_ELEMENT_ID IN (?)", elementIDs)
+	if err != nil {
+		errorMessage = err
+		return
+	}
+
+	rows, err := conn.Queryx(query, args...)
+	if err != nil {
+		errorMessage = err
+		return
+	}
+	defer rows.Close()
+
+	var elementID uint64
+	cells := make([]sql.NullString, len(columns)-1)
+	targets := []interface{}{&elementID}
+	for index := range cells {
+		targets = append(targets, &cells[index])
+	}
+
+	for rows.Next() {
+		err = rows.Scan(targets...)
+		if err != nil {
+			errorMessage = err
+			return
+		}
+
+		cell := 0
+		for _, property := range properties {
+			value := Value{ElementID: elementID, PropertyID: property.ID, Value: cells[cell]}
+			cell++
+			if property.HasDescription() {
+				value.Description = cells[cell]
+				cell++
+			}
+			if value.Value.Valid {
+				values = append(values, value)
+			}
+		}
+	}
+	errorMessage = rows.Err()
+
+	return
+}
+
+// Number - значение числом (N), не число - nil
+func (value Value) Number() interface{} {
+	if value.ValueNum.Valid {
+		return value.ValueNum.Float64
+	}
+	number, err := strconv.ParseFloat(value.Value.String, 64)
+	if err != nil {
+		return nil
+	}
+
+	return number
+}
+
+// EnumID - ID варианта списка (L)
+func (value Value) EnumID() uint64 {
+	if value.ValueEnum.Valid {
+		return uint64(value.ValueEnum.Int64)
+	}
+
+	return value.Uint()
+}
+
+// Uint - значение-ссылка на ID (E, G, F)
+func (value Value) Uint() uint64 {
+	id, _ := strconv.ParseUint(value.Value.String, 10, 64)
+
+	return id
+}
@@ -0,0 +1,50 @@
+package ndjson
+
+import (
+	"context"
+
+	"../filter"
+)
+
+// BatchSize - сколько строк читаем и дообогащаем за раз при потоковой выдаче
+const BatchSize = 500
+
+// Batches - обходим выборку страницами по BatchSize по курсору: fetch читает страницу params целиком,
+// закрывает выборку, дообогащает и отдает записи, last - значения сортировки последней строки
+// (колонка filter.CursorColumn). Поток держит не больше одного соединения пула, между страницами
+// соединение возвращается в пул. params.Limit > 0 - сколько записей отдать всего, OFFSET - только на первой странице.
+// Отмена ctx (клиент отключился) прерывает обход
+func Batches(ctx context.Context, params filter.Params, fetch func(ctx context.Context, params filter.Params) (count int, last filter.CursorValues, err error)) (errorMessage error) {
+	remaining := params.Limit
+	params.Cursor = true
+	for {
+		params.Limit = BatchSize
+		if remaining > 0 && remaining < BatchSize {
+			params.Limit = remaining
+		}
+
+		count, last, err := fetch(ctx, params)
+		if err != nil {
+			errorMessage = err
+			return
+		}
+		if count < params.Limit {
+			return
+		}
+		if remaining > 0 {
+			remaining -= count
+			if remaining == 0 {
+				return
+			}
+		}
+
+		errorMessage = ctx.Err()
+		if errorMessage != nil {
+			return
+		}
+		errorMessage = params.Continue(last)
+		if errorMessage != nil {
+			return
+		}
+	}
+}
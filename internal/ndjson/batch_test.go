@@ -0,0 +1,147 @@
+package ndjson
+
+import (
+	"context"
+	"database/sql"
+	"reflect"
+	"strconv"
+	"testing"
+
+	"../filter"
+)
+
+var testFields = map[string]string{"ID": "t.ID", "SORT": "t.SORT"}
+
+// testPages - выборка из total строк с ID 1..total, fetch запоминает запрошенные страницы
+type testPages struct {
+	total   int
+	queries []string
+	args    [][]interface{}
+}
+
+func (pages *testPages) fetch(ctx context.Context, params filter.Params) (count int, last filter.CursorValues, err error) {
+	where, args := params.WithKeyset("", nil)
+	pages.queries = append(pages.queries, where+params.SQL())
+	pages.args = append(pages.args, args)
+
+	from := params.Offset
+	if len(args) > 0 {
+		from, _ = strconv.Atoi(args[0].(string))
+	}
+	count = pages.total - from
+	if count > params.Limit {
+		count = params.Limit
+	}
+	if count <= 0 {
+		return 0, nil, nil
+	}
+	last = filter.CursorValues{{String: strconv.Itoa(from + count), Valid: true}}
+
+	return
+}
+
+func streamParams(t *testing.T, raw map[string]interface{}) filter.Params {
+	t.Helper()
+	params, err := filter.ParseStreamParams(raw, testFields, "ID ASC")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return params
+}
+
+func TestBatches(t *testing.T) {
+	tests := []struct {
+		name    string
+		total   int
+		raw     map[string]interface{}
+		queries []string
+	}{
+		{
+			name:  "all rows",
+			total: 1200,
+			raw:   map[string]interface{}{},
+			queries: []string{
+				" ORDER BY t.ID ASC LIMIT 500",
+				" WHERE ((t.ID > ?)) ORDER BY t.ID ASC LIMIT 500",
+				" WHERE ((t.ID > ?)) ORDER BY t.ID ASC LIMIT 500",
+			},
+		},
+		{
+			name:  "exact batches",
+			total: 1000,
+			raw:   map[string]interface{}{},
+			queries: []string{
+				" ORDER BY t.ID ASC LIMIT 500",
+				" WHERE ((t.ID > ?)) ORDER BY t.ID ASC LIMIT 500",
+				" WHERE ((t.ID > ?)) ORDER BY t.ID ASC LIMIT 500",
+			},
+		},
+		{
+			name:  "limit",
+			total: 1200,
+			raw:   map[string]interface{}{"LIMIT": 700},
+			queries: []string{
+				" ORDER BY t.ID ASC LIMIT 500",
+				" WHERE ((t.ID > ?)) ORDER BY t.ID ASC LIMIT 200",
+			},
+		},
+		{
+			name:  "offset on first page only",
+			total: 1200,
+			raw:   map[string]interface{}{"OFFSET": 20},
+			queries: []string{
+				" ORDER BY t.ID ASC LIMIT 500 OFFSET 20",
+				" WHERE ((t.ID > ?)) ORDER BY t.ID ASC LIMIT 500",
+				" WHERE ((t.ID > ?)) ORDER BY t.ID ASC LIMIT 500",
+			},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			pages := &testPages{total: test.total}
+			err := Batches(context.Background(), streamParams(t, test.raw), pages.fetch)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(pages.queries, test.queries) {
+				t.Errorf("queries:\n%q\nwant\n%q", pages.queries, test.queries)
+			}
+		})
+	}
+
+	pages := &testPages{total: 1200}
+	Batches(context.Background(), streamParams(t, map[string]interface{}{}), pages.fetch)
+	if !reflect.DeepEqual(pages.args[1:], [][]interface{}{{"500"}, {"1000"}}) {
+		t.Errorf("pages continue after %v, want 500 and 1000", pages.args[1:])
+	}
+}
+
+func TestBatchesCancel(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	pages := &testPages{total: 5000}
+	fetch := func(ctx context.Context, params filter.Params) (int, filter.CursorValues, error) {
+		cancel()
+		return pages.fetch(ctx, params)
+	}
+
+	err := Batches(ctx, streamParams(t, map[string]interface{}{}), fetch)
+	if err != context.Canceled {
+		t.Errorf("err = %v, want context.Canceled", err)
+	}
+	if len(pages.queries) != 1 {
+		t.Errorf("%d pages read after cancel, want 1", len(pages.queries))
+	}
+}
+
+func TestBatchesWithoutCursorColumn(t *testing.T) {
+	fetch := func(ctx context.Context, params filter.Params) (int, filter.CursorValues, error) {
+		return params.Limit, []sql.NullString{}, nil
+	}
+
+	err := Batches(context.Background(), streamParams(t, map[string]interface{}{}), fetch)
+	if err == nil {
+		t.Error("full page without CURSOR_VALUES: want error")
+	}
+}
@@ -0,0 +1,84 @@
+// Package ndjson - потоковая выдача списков по строке json на запись
+package ndjson
+
+import (
+	"encoding/json"
+	"net/http"
+	"strings"
+)
+
+// ContentType - тип ответа, который клиент просит в заголовке Accept
+const ContentType = "application/x-ndjson"
+
+// flushEvery - сколько записей отправлять клиенту за раз
+const flushEvery = 100
+
+// Accepts - клиент просит потоковую выдачу
+func Accepts(request *http.Request) bool {
+	return strings.Contains(request.Header.Get("Accept"), ContentType)
+}
+
+// Writer - пишет записи в ответ, заголовки отправляются с первой записью
+type Writer struct {
+	response http.ResponseWriter
+	started  bool
+	pending  int
+}
+
+// NewWriter - потоковый ответ поверх response
+func NewWriter(response http.ResponseWriter) *Writer {
+	return &Writer{response: response}
+}
+
+// Write - одна запись одной строкой
+func (writer *Writer) Write(item interface{}) (errorMessage error) {
+	line, errorMessage := json.Marshal(item)
+	if errorMessage != nil {
+		return
+	}
+
+	writer.start()
+	_, errorMessage = writer.response.Write(append(line, '\n'))
+	if errorMessage != nil {
+		return
+	}
+
+	writer.pending++
+	if writer.pending >= flushEvery {
+		writer.flush()
+	}
+
+	return
+}
+
+// Close - завершаем ответ. Ошибка до первой записи - 400, после - последней строкой {"error": "..."}
+func (writer *Writer) Close(err error) {
+	if err != nil && !writer.started {
+		writer.response.WriteHeader(http.StatusBadRequest)
+		writer.response.Write([]byte(err.Error()))
+		return
+	}
+
+	writer.start()
+	if err != nil {
+		line, _ := json.Marshal(map[string]string{"error": err.Error()})
+		writer.response.Write(append(line, '\n'))
+	}
+	writer.flush()
+}
+
+func (writer *Writer) start() {
+	if writer.started {
+		return
+	}
+	writer.started = true
+	writer.response.Header().Set("Content-Type", ContentType)
+	writer.response.WriteHeader(http.StatusOK)
+}
+
+func (writer *Writer) flush() {
+	writer.pending = 0
+	if flusher, ok := writer.response.(http.Flusher); ok {
+		flusher.Flush()
+	}
+}
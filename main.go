@@ -1,6 +1,7 @@
 package main
 
 import (
+	"log"
 	"net/http"
 
 	"github.com/gorilla/mux"
@@ -9,31 +10,78 @@ import (
 	"./catalog"
 	"./delivery"
 	"./element"
+	"./file"
+	"./internal/database"
 	"./section"
 )
 
 func main() {
+	env, err := database.LoadEnv("env.yml")
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	conn, err := database.Open(env)
+	if err != nil {
+		log.Fatal(err)
+	}
+	defer conn.Close()
+
+	baskets := basket.NewRepository(conn, env.SiteID)
+	products, err := catalog.NewRepository(conn)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	basketHandler := basket.NewHandler(baskets)
+	catalogHandler := catalog.NewHandler(products)
+	deliveryHandler, err := delivery.NewHandler(baskets, products, "delivery.yml")
+	if err != nil {
+		log.Fatal(err)
+	}
+	elementHandler := element.NewHandler(element.NewRepository(conn, products, env.UploadURL, env.SiteID))
+	sectionHandler := section.NewHandler(section.NewRepository(conn, env.UploadURL, env.SiteID))
+	fileHandler := file.NewHandler(file.NewRepository(conn, env.UploadURL), file.Config{
+		UploadDir: env.UploadDir,
+		CacheDir:  env.ResizeCacheDir,
+		Sizes:     env.ResizeSizes,
+		Workers:   env.ResizeWorkers,
+	})
+
 	router := mux.NewRouter()
 
-	router.HandleFunc("/kse/moscow/calc/", delivery.Provide).Methods("POST")
-	router.HandleFunc("/kse/spb/calc/", delivery.Provide).Methods("POST")
-	router.HandleFunc("/kse/moscow-obl/calc/", delivery.Provide).Methods("POST")
-	router.HandleFunc("/kse/spb-obl/calc/", delivery.Provide).Methods("POST")
-	router.HandleFunc("/basket/{fuser_id:[0-9]+}/items/", basket.Items).Methods("GET")
-	router.HandleFunc("/basket/{fuser_id:[0-9]+}/product/{product_id:[0-9]+}/", basket.Product).Methods("GET")
-	router.HandleFunc("/basket/{fuser_id:[0-9]+}/count/", basket.Count).Methods("GET")
-	router.HandleFunc("/basket/{fuser_id:[0-9]+}/cost/", basket.Cost).Methods("GET")
-	router.HandleFunc("/basket/{fuser_id:[0-9]+}/weight/", basket.Weight).Methods("GET")
-	router.HandleFunc("/catalog/{product_id:[0-9]+}/info/", catalog.Info).Methods("GET")
-	router.HandleFunc("/catalog/{product_id:[0-9]+}/have-offers/", catalog.HaveOffers).Methods("GET")
-	router.HandleFunc("/element/{element_id:[0-9]+}/info/", element.InfoByID).Methods("GET")
-	router.HandleFunc("/element/{element_code:[a-zA-Z-_0-9]+}/info/", element.InfoByCode).Methods("GET")
-	router.HandleFunc("/element/list/", element.List).Methods("POST")
-	router.HandleFunc("/element/{element_id:[0-9]+}/props/", element.GetProperties).Methods("GET")
-	router.HandleFunc("/section/{section_id:[0-9]+}/info/", section.InfoByID).Methods("GET")
-	router.HandleFunc("/section/{section_code:[a-zA-Z-_0-9]+}/info/", section.InfoByCode).Methods("GET")
-	router.HandleFunc("/section/list/", section.List).Methods("POST")
+	router.HandleFunc("/kse/moscow/calc/", deliveryHandler.Provide).Methods("POST")
+	router.HandleFunc("/kse/spb/calc/", deliveryHandler.Provide).Methods("POST")
+	router.HandleFunc("/kse/moscow-obl/calc/", deliveryHandler.Provide).Methods("POST")
+	router.HandleFunc("/kse/spb-obl/calc/", deliveryHandler.Provide).Methods("POST")
+	router.HandleFunc("/basket/{fuser_id:[0-9]+}/items/", basketHandler.Items).Methods("GET")
+	router.HandleFunc("/basket/{fuser_id:[0-9]+}/items/", basketHandler.Add).Methods("POST")
+	router.HandleFunc("/basket/{fuser_id:[0-9]+}/items/", basketHandler.Clear).Methods("DELETE")
+	router.HandleFunc("/basket/{fuser_id:[0-9]+}/product/{product_id:[0-9]+}/", basketHandler.Product).Methods("GET")
+	router.HandleFunc("/basket/{fuser_id:[0-9]+}/product/{product_id:[0-9]+}/", basketHandler.Update).Methods("PATCH")
+	router.HandleFunc("/basket/{fuser_id:[0-9]+}/product/{product_id:[0-9]+}/", basketHandler.Remove).Methods("DELETE")
+	router.HandleFunc("/basket/{fuser_id:[0-9]+}/count/", basketHandler.Count).Methods("GET")
+	router.HandleFunc("/basket/{fuser_id:[0-9]+}/cost/", basketHandler.Cost).Methods("GET")
+	router.HandleFunc("/basket/{fuser_id:[0-9]+}/weight/", basketHandler.Weight).Methods("GET")
+	router.HandleFunc("/basket/{fuser_id:[0-9]+}/summary/", basketHandler.Summarize).Methods("GET")
+	router.HandleFunc("/catalog/{product_id:[0-9]+}/info/", catalogHandler.Info).Methods("GET")
+	router.HandleFunc("/catalog/{product_id:[0-9]+}/have-offers/", catalogHandler.HaveOffers).Methods("GET")
+	router.HandleFunc("/catalog/{product_id:[0-9]+}/offers/", catalogHandler.Offers).Methods("GET")
+	router.HandleFunc("/catalog/{product_id:[0-9]+}/stores/", catalogHandler.Stores).Methods("GET")
+	router.HandleFunc("/catalog/stores/", catalogHandler.BulkStores).Methods("POST")
+	router.HandleFunc("/catalog/info/", catalogHandler.BulkInfo).Methods("POST")
+	router.HandleFunc("/element/{element_id:[0-9]+}/info/", elementHandler.InfoByID).Methods("GET")
+	router.HandleFunc("/element/{element_code:[a-zA-Z-_0-9]+}/info/", elementHandler.InfoByCode).Methods("GET")
+	router.HandleFunc("/element/list/", elementHandler.List).Methods("POST")
+	router.HandleFunc("/element/{element_id:[0-9]+}/props/", elementHandler.GetProperties).Methods("GET")
+	router.HandleFunc("/element/{element_id:[0-9]+}/path/", elementHandler.Path).Methods("GET")
+	router.HandleFunc("/section/{section_id:[0-9]+}/info/", sectionHandler.InfoByID).Methods("GET")
+	router.HandleFunc("/section/{section_code:[a-zA-Z-_0-9]+}/info/", sectionHandler.InfoByCode).Methods("GET")
+	router.HandleFunc("/section/list/", sectionHandler.List).Methods("POST")
+	router.HandleFunc("/section/tree/", sectionHandler.Tree).Methods("GET")
+	router.HandleFunc("/section/{section_id:[0-9]+}/path/", sectionHandler.Path).Methods("GET")
+	router.HandleFunc("/file/{file_id:[0-9]+}/", fileHandler.Image).Methods("GET")
 
 	http.Handle("/", router)
-	http.ListenAndServe(":9000", nil)
+	log.Fatal(http.ListenAndServe(":9000", nil))
 }
@@ -0,0 +1,106 @@
+package section
+
+import (
+	"strconv"
+	"testing"
+)
+
+// listBenchmarks - все разделы инфоблока с разными связями
+var listBenchmarks = []struct {
+	name   string
+	expand []string
+}{
+	{"plain", []string{}},
+	{"children", []string{"children"}},
+	{"meta", []string{"meta"}},
+	{"properties", []string{"properties"}},
+	{"url", []string{"url"}},
+	{"children+meta+properties+url", nil},
+}
+
+const fixtureSections = fixtureTopSections * (fixtureSubSections + 1)
+
+func benchmarkQuery(expand []string) Query {
+	return Query{
+		Filter: map[string]interface{}{"IBLOCK_ID": fixtureIblockID},
+		Params: map[string]interface{}{"LIMIT": fixtureSections},
+		Expand: expand,
+	}
+}
+
+// BenchmarkList - список разделов, связи грузятся одним запросом на пачку,
+// запуск: BITRIX_TEST_DSN='root:root@tcp(127.0.0.1:3306)/' BITRIX_BENCH_RECREATE=Y go test -run NONE -bench . ./section
+func BenchmarkList(b *testing.B) {
+	repo := NewRepository(openFixture(b), "/upload/", fixtureSiteID)
+
+	for _, benchmark := range listBenchmarks {
+		query := benchmarkQuery(benchmark.expand)
+		b.Run(benchmark.name, func(b *testing.B) {
+			b.ReportAllocs()
+			for i := 0; i < b.N; i++ {
+				result, err := repo.List(query)
+				if err != nil {
+					b.Fatal(err)
+				}
+				if len(result.Items) != fixtureSections {
+					b.Fatalf("got %d sections, want %d", len(result.Items), fixtureSections)
+				}
+			}
+		})
+	}
+}
+
+// BenchmarkListPerSection - тот же список, но связи грузятся запросами на каждый раздел,
+// как до загрузки пачками: база для сравнения с BenchmarkList
+func BenchmarkListPerSection(b *testing.B) {
+	repo := NewRepository(openFixture(b), "/upload/", fixtureSiteID).(*repository)
+
+	for _, benchmark := range listBenchmarks {
+		query := benchmarkQuery(benchmark.expand)
+		selection, err := parseSelection(query)
+		if err != nil {
+			b.Fatal(err)
+		}
+		where, args, err := prepareFilter(query.Filter)
+		if err != nil {
+			b.Fatal(err)
+		}
+
+		b.Run(benchmark.name, func(b *testing.B) {
+			b.ReportAllocs()
+			for i := 0; i < b.N; i++ {
+				var sections []*Section
+				err := repo.conn.Select(&sections, "SELECT "+selectColumns(selection)+
+					" FROM `b_iblock_section` t "+where+" ORDER BY t.SORT ASC, t.ID ASC LIMIT "+strconv.Itoa(fixtureSections), args...)
+				if err != nil {
+					b.Fatal(err)
+				}
+				for _, section := range sections {
+					err = repo.enrich([]*Section{section}, selection)
+					if err != nil {
+						b.Fatal(err)
+					}
+				}
+				if len(sections) != fixtureSections {
+					b.Fatalf("got %d sections, want %d", len(sections), fixtureSections)
+				}
+			}
+		})
+	}
+}
+
+// BenchmarkTree - все дерево инфоблока одним запросом
+func BenchmarkTree(b *testing.B) {
+	repo := NewRepository(openFixture(b), "/upload/", fixtureSiteID)
+
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		tree, err := repo.Tree(TreeQuery{IblockID: fixtureIblockID})
+		if err != nil {
+			b.Fatal(err)
+		}
+		if len(tree) != fixtureTopSections {
+			b.Fatalf("got %d top sections, want %d", len(tree), fixtureTopSections)
+		}
+	}
+}
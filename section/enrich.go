@@ -0,0 +1,309 @@
+package section
+
+import (
+	"database/sql"
+	"strconv"
+	"strings"
+
+	"github.com/jmoiron/sqlx"
+
+	"../internal/filter"
+	"../internal/iblock"
+)
+
+// propertyFields - пользовательские поля раздела, в ответе ключом без UF_ в нижнем регистре
+var propertyFields = []string{
+	"UF_ALT_LINK",
+	"UF_BANNER",
+	"UF_CODE",
+	"UF_COMBUSTION",
+	"UF_DESCRIPTION_ARCH",
+	"UF_DESCRIPTION_B2B",
+	"UF_EMAIL_TO",
+	"UF_H1_ARCH",
+	"UF_H1_B2B",
+	"UF_KEYWORDS_ARCH",
+	"UF_KEYWORDS_B2B",
+	"UF_MAKE_OFFER_BETTER",
+	"UF_PROMO_END",
+	"UF_PROMO_START",
+	"UF_PROPERTY_CODE",
+	"UF_RULES_FILE",
+	"UF_TITLE_ARCH",
+	"UF_TITLE_B2B",
+}
+
+// expansions - связи, которые можно подгрузить к разделу (expand)
+var expansions = []string{"meta", "properties", "section", "children", "url"}
+
+// defaultExpand - связи, если expand не передан
+var defaultExpand = []string{"children", "meta", "properties", "url"}
+
+// parseSelection - select и expand запроса
+func parseSelection(query Query) (filter.Selection, error) {
+	return filter.ParseSelection(query.Select, query.Expand, fields, expansions, defaultExpand)
+}
+
+// selectColumns - колонки выборки, поля нужные для связей берем всегда
+func selectColumns(selection filter.Selection) string {
+	var required []string
+	if selection.Expands("properties") {
+		required = append(required, "IBLOCK_ID")
+	}
+	if selection.Expands("section") {
+		required = append(required, "IBLOCK_SECTION_ID")
+	}
+
+	return selection.Columns(fields, required...)
+}
+
+// enrich - подгружаем запрошенные связи для пачки разделов, по запросу на связь
+func (repo *repository) enrich(sections []*Section, selection filter.Selection) (errorMessage error) {
+	byID := make(map[uint64]*Section, len(sections))
+	ids := make([]uint64, 0, len(sections))
+	for _, section := range sections {
+		section.selection = selection
+		byID[section.ID] = section
+		ids = append(ids, section.ID)
+	}
+	if len(sections) == 0 {
+		return
+	}
+
+	if selection.Selected("PICTURE") {
+		pictures := make([]*iblock.Picture, 0, len(sections))
+		for _, section := range sections {
+			pictures = append(pictures, &section.Picture)
+		}
+		errorMessage = iblock.ResolvePictures(repo.conn, repo.uploadURL, pictures)
+		if errorMessage != nil {
+			return
+		}
+	}
+	if selection.Expands("children") {
+		errorMessage = repo.loadChildElements(ids, byID)
+		if errorMessage != nil {
+			return
+		}
+	}
+	if selection.Expands("meta") {
+		errorMessage = repo.loadMeta(ids, byID)
+		if errorMessage != nil {
+			return
+		}
+	}
+	if selection.Expands("properties") {
+		errorMessage = repo.loadProperties(sections, byID)
+		if errorMessage != nil {
+			return
+		}
+	}
+	if selection.Expands("section") {
+		errorMessage = repo.loadParents(sections)
+		if errorMessage != nil {
+			return
+		}
+	}
+	if selection.Expands("url") {
+		errorMessage = repo.loadURLs(ids, byID)
+	}
+
+	return
+}
+
+// loadChildElements - привязки элементов к разделам пачки
+func (repo *repository) loadChildElements(ids []uint64, byID map[uint64]*Section) (errorMessage error) {
+	for _, section := range byID {
+		section.Elements = []uint64{}
+	}
+
+	query, args, err := sqlx.In("SELECT IBLOCK_SECTION_ID, IBLOCK_ELEMENT_ID FROM b_iblock_section_element"+
+		" WHERE IBLOCK_SECTION_ID IN (?)", ids)
+	if err != nil {
+		errorMessage = err
+		return
+	}
+
+	rows, err := repo.conn.Queryx(query, args...)
+	if err != nil {
+		errorMessage = err
+		return
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var sectionID, elementID uint64
+		err = rows.Scan(&sectionID, &elementID)
+		if err != nil {
+			errorMessage = err
+			return
+		}
+		if section, found := byID[sectionID]; found {
+			section.Elements = append(section.Elements, elementID)
+		}
+	}
+	errorMessage = rows.Err()
+
+	return
+}
+
+// loadMeta - мета (SEO) разделов пачки
+func (repo *repository) loadMeta(ids []uint64, byID map[uint64]*Section) (errorMessage error) {
+	for _, section := range byID {
+		section.Meta = make(map[string]string)
+	}
+
+	query, args, err := sqlx.In("SELECT si.SECTION_ID, ip.CODE, si.VALUE"+
+		" FROM b_iblock_section_iprop si"+
+		" INNER JOIN b_iblock_iproperty ip ON ip.ID = si.IPROP_ID"+
+		" WHERE si.SECTION_ID IN (?)", ids)
+	if err != nil {
+		errorMessage = err
+		return
+	}
+
+	rows, err := repo.conn.Queryx(query, args...)
+	if err != nil {
+		errorMessage = err
+		return
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var sectionID uint64
+		var metaName, metaValue string
+		err = rows.Scan(&sectionID, &metaName, &metaValue)
+		if err != nil {
+			errorMessage = err
+			return
+		}
+		if section, found := byID[sectionID]; found {
+			section.Meta[metaName] = metaValue
+		}
+	}
+	errorMessage = rows.Err()
+
+	return
+}
+
+// loadProperties - пользовательские поля разделов пачки, по запросу на инфоблок
+func (repo *repository) loadProperties(sections []*Section, byID map[uint64]*Section) (errorMessage error) {
+	byIblock := make(map[uint64][]uint64)
+	for _, section := range sections {
+		section.Props = make(map[string]string)
+		byIblock[section.IblockID] = append(byIblock[section.IblockID], section.ID)
+	}
+
+	for iblockID, ids := range byIblock {
+		query, args, err := sqlx.In("SELECT VALUE_ID, "+strings.Join(propertyFields, ", ")+
+			" FROM `b_uts_iblock_"+strconv.FormatUint(iblockID, 10)+"_section`"+
+			" WHERE VALUE_ID IN (?)", ids)
+		if err != nil {
+			errorMessage = err
+			return
+		}
+
+		errorMessage = repo.scanProperties(query, args, byID)
+		if errorMessage != nil {
+			return
+		}
+	}
+
+	return
+}
+
+func (repo *repository) scanProperties(query string, args []interface{}, byID map[uint64]*Section) (errorMessage error) {
+	rows, err := repo.conn.Queryx(query, args...)
+	if err != nil {
+		errorMessage = err
+		return
+	}
+	defer rows.Close()
+
+	values := make([]sql.NullString, len(propertyFields))
+	targets := make([]interface{}, len(propertyFields)+1)
+	for i := range values {
+		targets[i+1] = &values[i]
+	}
+
+	for rows.Next() {
+		var sectionID uint64
+		targets[0] = &sectionID
+		err = rows.Scan(targets...)
+		if err != nil {
+			errorMessage = err
+			return
+		}
+
+		section, found := byID[sectionID]
+		if !found {
+			continue
+		}
+		for i, field := range propertyFields {
+			section.Props[propertyKey(field)] = values[i].String
+		}
+	}
+	errorMessage = rows.Err()
+
+	return
+}
+
+// loadParents - родительские разделы для пачки разделов
+func (repo *repository) loadParents(sections []*Section) (errorMessage error) {
+	var ids []int64
+	for _, section := range sections {
+		if section.IblockSectionID.Valid {
+			ids = append(ids, section.IblockSectionID.Int64)
+		}
+	}
+	if len(ids) == 0 {
+		return
+	}
+
+	query, args, err := sqlx.In("SELECT ID, CODE, XML_ID, NAME, IBLOCK_SECTION_ID, DEPTH_LEVEL"+
+		" FROM b_iblock_section"+
+		" WHERE ID IN (?)", ids)
+	if err != nil {
+		errorMessage = err
+		return
+	}
+
+	var parents []*Parent
+	errorMessage = repo.conn.Select(&parents, query, args...)
+	if errorMessage != nil {
+		return
+	}
+
+	byID := make(map[int64]*Parent, len(parents))
+	for _, parent := range parents {
+		byID[int64(parent.ID)] = parent
+	}
+	for _, section := range sections {
+		if section.IblockSectionID.Valid {
+			section.Parent = byID[section.IblockSectionID.Int64]
+		}
+	}
+
+	return
+}
+
+// loadURLs - SECTION_PAGE_URL и LIST_PAGE_URL по шаблонам инфоблоков для разделов пачки
+func (repo *repository) loadURLs(ids []uint64, byID map[uint64]*Section) (errorMessage error) {
+	urls, errorMessage := iblock.SectionURLs(repo.conn, repo.siteID, ids)
+	if errorMessage != nil {
+		return
+	}
+	for sectionID, url := range urls {
+		if section, found := byID[sectionID]; found {
+			section.SectionPageURL = url.Page
+			section.ListPageURL = url.List
+		}
+	}
+
+	return
+}
+
+// propertyKey - UF_ALT_LINK -> alt_link
+func propertyKey(field string) string {
+	return strings.ToLower(strings.TrimPrefix(field, "UF_"))
+}
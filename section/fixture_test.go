@@ -0,0 +1,104 @@
+package section
+
+import (
+	"io/ioutil"
+	"os"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/go-sql-driver/mysql"
+	"github.com/jmoiron/sqlx"
+)
+
+// схема ../testdata/bench.sql для бенчмарков
+const (
+	fixtureSiteID   = "s1"
+	fixtureIblockID = 1
+	fixtureDB       = "bitrix_bench"
+	// разделы верхнего уровня и подразделы у каждого из них
+	fixtureTopSections = 10
+	fixtureSubSections = 4
+)
+
+var (
+	fixtureOnce sync.Once
+	fixtureConn *sqlx.DB
+	fixtureErr  error
+)
+
+// openFixture - база bitrix_bench, пересозданная из ../testdata/bench.sql один раз на процесс.
+// BITRIX_TEST_DSN - подключение к локальному mysql (база из DSN не используется), BITRIX_BENCH_RECREATE=Y -
+// согласие на DROP DATABASE bitrix_bench. Без них бенчмарк пропускается
+func openFixture(b *testing.B) *sqlx.DB {
+	dsn := os.Getenv("BITRIX_TEST_DSN")
+	if dsn == "" || os.Getenv("BITRIX_BENCH_RECREATE") != "Y" {
+		b.Skip("BITRIX_TEST_DSN and BITRIX_BENCH_RECREATE=Y are required, database " + fixtureDB + " is dropped and created")
+	}
+
+	fixtureOnce.Do(func() {
+		fixtureConn, fixtureErr = createFixture(dsn)
+	})
+	if fixtureErr != nil {
+		b.Fatal(fixtureErr)
+	}
+
+	return fixtureConn
+}
+
+func createFixture(dsn string) (conn *sqlx.DB, errorMessage error) {
+	script, err := ioutil.ReadFile("../testdata/bench.sql")
+	if err != nil {
+		errorMessage = err
+		return
+	}
+
+	config, err := mysql.ParseDSN(dsn)
+	if err != nil {
+		errorMessage = err
+		return
+	}
+	config.DBName = ""
+	server, err := sqlx.Connect("mysql", config.FormatDSN())
+	if err != nil {
+		errorMessage = err
+		return
+	}
+	defer server.Close()
+	for _, query := range []string{"DROP DATABASE IF EXISTS " + fixtureDB, "CREATE DATABASE " + fixtureDB + " CHARACTER SET utf8mb4"} {
+		_, errorMessage = server.Exec(query)
+		if errorMessage != nil {
+			return
+		}
+	}
+
+	config.DBName = fixtureDB
+	conn, errorMessage = sqlx.Connect("mysql", config.FormatDSN())
+	if errorMessage != nil {
+		return
+	}
+	for _, query := range strings.Split(string(script), ";\n") {
+		query = strings.TrimSpace(stripComments(query))
+		if query == "" {
+			continue
+		}
+		_, errorMessage = conn.Exec(query)
+		if errorMessage != nil {
+			return
+		}
+	}
+
+	return
+}
+
+// stripComments - запрос без строк комментариев "--"
+func stripComments(query string) string {
+	var lines []string
+	for _, line := range strings.Split(query, "\n") {
+		if !strings.HasPrefix(strings.TrimSpace(line), "--") {
+			lines = append(lines, line)
+		}
+	}
+
+	return strings.Join(lines, "\n")
+}
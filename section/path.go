@@ -0,0 +1,35 @@
+package section
+
+import (
+	"../internal/iblock"
+)
+
+// Path - цепочка разделов от верхнего уровня до раздела включительно и ссылка на раздел
+type Path struct {
+	Items          []iblock.PathSection `json:"items"`
+	SectionPageURL string               `json:"section_page_url"`
+}
+
+// Path - хлебные крошки раздела по LEFT_MARGIN/RIGHT_MARGIN со ссылками по шаблону инфоблока, nil - раздела нет
+func (repo *repository) Path(sectionID uint64) (path *Path, errorMessage error) {
+	paths, errorMessage := iblock.SectionPaths(repo.conn, []uint64{sectionID})
+	if errorMessage != nil {
+		return
+	}
+	items, found := paths[sectionID]
+	if !found {
+		return
+	}
+
+	iblocks, errorMessage := iblock.FindIblocks(repo.conn, repo.siteID, []uint64{items[0].IblockID})
+	if errorMessage != nil {
+		return
+	}
+	if info, found := iblocks[items[0].IblockID]; found {
+		info.SetURLs(items)
+	}
+
+	path = &Path{Items: items, SectionPageURL: items[len(items)-1].SectionPageURL}
+
+	return
+}
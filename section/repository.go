@@ -0,0 +1,205 @@
+package section
+
+import (
+	"context"
+	"strings"
+
+	"github.com/jmoiron/sqlx"
+
+	"../internal/filter"
+)
+
+// Repository - хранилище разделов инфоблока
+type Repository interface {
+	List(query Query) (ListResult, error)
+	Find(query Query) ([]*Section, error)
+	Stream(ctx context.Context, query Query, emit func(section *Section) error) error
+	Tree(query TreeQuery) ([]*TreeNode, error)
+	Path(sectionID uint64) (*Path, error)
+}
+
+type repository struct {
+	conn      *sqlx.DB
+	uploadURL string
+	siteID    string
+}
+
+var fields = []string{
+	"ID",
+	"CODE",
+	"XML_ID ",
+	"NAME",
+	"IBLOCK_ID",
+	"IBLOCK_SECTION_ID",
+	"ACTIVE",
+	"SORT",
+	"DEPTH_LEVEL",
+	"PICTURE",
+	"DESCRIPTION",
+	"SEARCHABLE_CONTENT",
+	"DATE_CREATE",
+	"CREATED_BY",
+	"TIMESTAMP_X",
+	"MODIFIED_BY",
+}
+
+// filterFields - поля, по которым можно фильтровать
+var filterFields = map[string]string{
+	"ID":                 "t.ID",
+	"CODE":               "t.CODE",
+	"XML_ID":             "t.XML_ID",
+	"NAME":               "t.NAME",
+	"IBLOCK_ID":          "t.IBLOCK_ID",
+	"IBLOCK_SECTION_ID":  "t.IBLOCK_SECTION_ID",
+	"ACTIVE":             "t.ACTIVE",
+	"GLOBAL_ACTIVE":      "t.GLOBAL_ACTIVE",
+	"SORT":               "t.SORT",
+	"PICTURE":            "t.PICTURE",
+	"DESCRIPTION":        "t.DESCRIPTION",
+	"SEARCHABLE_CONTENT": "t.SEARCHABLE_CONTENT",
+	"DATE_CREATE":        "t.DATE_CREATE",
+	"CREATED_BY":         "t.CREATED_BY",
+	"TIMESTAMP_X":        "t.TIMESTAMP_X",
+	"MODIFIED_BY":        "t.MODIFIED_BY",
+	"DEPTH_LEVEL":        "t.DEPTH_LEVEL",
+	"LEFT_MARGIN":        "t.LEFT_MARGIN",
+	"RIGHT_MARGIN":       "t.RIGHT_MARGIN",
+}
+
+// NewRepository - хранилище разделов поверх общего пула соединений, uploadURL - начало src картинок,
+// siteID - сайт для #SITE_DIR# в ссылках
+func NewRepository(conn *sqlx.DB, uploadURL string, siteID string) Repository {
+	return &repository{conn: conn, uploadURL: uploadURL, siteID: siteID}
+}
+
+func (repo *repository) List(query Query) (result ListResult, errorMessage error) {
+	params, err := filter.ParseParams(query.Params, filterFields, "SORT ASC")
+	if err != nil {
+		errorMessage = err
+		return
+	}
+
+	selection, err := parseSelection(query)
+	if err != nil {
+		errorMessage = err
+		return
+	}
+
+	where, args, err := prepareFilter(query.Filter)
+	if err != nil {
+		errorMessage = err
+		return
+	}
+
+	if params.Cursor {
+		result, errorMessage = repo.listByCursor(params, selection, where, args)
+		return
+	}
+
+	columns := selectColumns(selection)
+	if len(params.Group) > 0 {
+		columns, errorMessage = params.GroupColumns(selection, query.Expand)
+		if errorMessage != nil {
+			return
+		}
+		// у сгруппированных строк связей нет
+		selection = filter.Selection{Fields: selection.Fields}
+	}
+
+	total, err := repo.count(where, args, params.Group)
+	if err != nil {
+		errorMessage = err
+		return
+	}
+
+	result.Items, errorMessage = repo.find(where, args, params.SQL(), selection, columns)
+	if result.Items == nil {
+		result.Items = []*Section{}
+	}
+	result.Pagination = params.Pagination(total)
+
+	return
+}
+
+// listByCursor - страница после курсора, без подсчета общего количества
+func (repo *repository) listByCursor(params filter.Params, selection filter.Selection, where string, args []interface{}) (result ListResult, errorMessage error) {
+	where, args = params.WithKeyset(where, args)
+
+	result.Items, errorMessage = repo.find(where, args, params.SQL(), selection, selectColumns(selection)+params.CursorColumns())
+	if errorMessage != nil {
+		return
+	}
+	if result.Items == nil {
+		result.Items = []*Section{}
+	}
+
+	var nextCursor string
+	if len(result.Items) == params.Limit {
+		nextCursor, errorMessage = params.NextCursor(result.Items[len(result.Items)-1].Cursor)
+	}
+	result.Pagination = params.CursorPagination(nextCursor)
+
+	return
+}
+
+func (repo *repository) Find(query Query) (sections []*Section, errorMessage error) {
+	selection, err := parseSelection(query)
+	if err != nil {
+		errorMessage = err
+		return
+	}
+
+	where, args, err := prepareFilter(query.Filter)
+	if err != nil {
+		errorMessage = err
+		return
+	}
+
+	sections, errorMessage = repo.find(where, args, "", selection, selectColumns(selection))
+
+	return
+}
+
+// count - общее количество записей по фильтру для пагинации
+func (repo *repository) count(where string, args []interface{}, group []string) (total int, errorMessage error) {
+	query := "SELECT COUNT(*) FROM `b_iblock_section` t " + where
+	if len(group) > 0 {
+		query = "SELECT COUNT(*) FROM (SELECT 1 FROM `b_iblock_section` t " + where +
+			" GROUP BY " + strings.Join(group, ", ") + ") g"
+	}
+
+	errorMessage = repo.conn.Get(&total, query, args...)
+
+	return
+}
+
+// find - записи по фильтру, columns - колонки SELECT (с CURSOR_VALUES при обходе по курсору)
+func (repo *repository) find(where string, args []interface{}, params string, selection filter.Selection, columns string) (sections []*Section, errorMessage error) {
+	table := " FROM `b_iblock_section` t "
+
+	query := "SELECT " + columns +
+		table +
+		where +
+		params
+
+	err := repo.conn.Select(&sections, query, args...)
+	if err != nil {
+		errorMessage = err
+		return
+	}
+
+	errorMessage = repo.enrich(sections, selection)
+
+	return
+}
+
+func prepareFilter(rawFilter map[string]interface{}) (where string, values []interface{}, errorMessage error) {
+	parser := filter.Parser{Fields: filterFields}
+
+	condition, values, errorMessage := parser.Parse(rawFilter)
+	if condition != "" {
+		where = " WHERE " + condition
+	}
+
+	return
+}
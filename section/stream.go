@@ -0,0 +1,61 @@
+package section
+
+import (
+	"context"
+
+	"../internal/filter"
+	"../internal/ndjson"
+)
+
+// Stream - отдаем разделы по одному, выборка читается по курсору пачками, связи подгружаются после того,
+// как выборка пачки закрыта - поток держит не больше одного соединения пула
+func (repo *repository) Stream(ctx context.Context, query Query, emit func(section *Section) error) (errorMessage error) {
+	params, err := filter.ParseStreamParams(query.Params, filterFields, "SORT ASC")
+	if err != nil {
+		errorMessage = err
+		return
+	}
+
+	selection, err := parseSelection(query)
+	if err != nil {
+		errorMessage = err
+		return
+	}
+
+	where, args, err := prepareFilter(query.Filter)
+	if err != nil {
+		errorMessage = err
+		return
+	}
+
+	fetch := func(ctx context.Context, params filter.Params) (count int, last filter.CursorValues, err error) {
+		pageWhere, pageArgs := params.WithKeyset(where, args)
+		selectQuery := "SELECT " + selectColumns(selection) + params.CursorColumns() +
+			" FROM `b_iblock_section` t " +
+			pageWhere +
+			params.SQL()
+
+		var batch []*Section
+		err = repo.conn.SelectContext(ctx, &batch, selectQuery, pageArgs...)
+		if err != nil || len(batch) == 0 {
+			return
+		}
+
+		err = repo.enrich(batch, selection)
+		if err != nil {
+			return
+		}
+		for _, section := range batch {
+			err = emit(section)
+			if err != nil {
+				return
+			}
+		}
+
+		return len(batch), batch[len(batch)-1].Cursor, nil
+	}
+
+	errorMessage = ndjson.Batches(ctx, params, fetch)
+
+	return
+}
@@ -0,0 +1,122 @@
+package section
+
+import (
+	"errors"
+	"net/url"
+	"strconv"
+	"strings"
+
+	"../api"
+	"../internal/database"
+)
+
+// TreeQuery - параметры дерева разделов, тип общий с client
+type TreeQuery = api.TreeQuery
+
+// TreeNode - раздел в дереве с подразделами в порядке сортировки
+type TreeNode struct {
+	ID              uint64              `db:"ID" json:"id"`
+	Code            database.NullString `db:"CODE" json:"code"`
+	XMLID           database.NullString `db:"XML_ID" json:"xml_id"`
+	Name            string              `db:"NAME" json:"name"`
+	IblockSectionID database.NullInt64  `db:"IBLOCK_SECTION_ID" json:"iblock_section_id"`
+	Active          database.Bool       `db:"ACTIVE" json:"active"`
+	Sort            uint64              `db:"SORT" json:"sort"`
+	DepthLevel      uint64              `db:"DEPTH_LEVEL" json:"depth_level"`
+	LeftMargin      uint64              `db:"LEFT_MARGIN" json:"-"`
+	RightMargin     uint64              `db:"RIGHT_MARGIN" json:"-"`
+	Children        []*TreeNode         `json:"children"`
+}
+
+// ParseTreeQuery - параметры дерева из строки запроса (?iblock_id=1&root=10&depth=2&active=Y)
+func ParseTreeQuery(values url.Values) (query TreeQuery, errorMessage error) {
+	for name, target := range map[string]*uint64{"iblock_id": &query.IblockID, "root": &query.RootID, "depth": &query.Depth} {
+		value := values.Get(name)
+		if value == "" {
+			continue
+		}
+		number, err := strconv.ParseUint(value, 10, 64)
+		if err != nil {
+			errorMessage = errors.New("invalid " + name + " " + value)
+			return
+		}
+		*target = number
+	}
+	if query.IblockID == 0 && query.RootID == 0 {
+		errorMessage = errors.New("iblock_id or root is required")
+		return
+	}
+
+	switch values.Get("active") {
+	case "", "N", "false", "0":
+	default:
+		query.ActiveOnly = true
+	}
+
+	return
+}
+
+// Tree - дерево разделов одним запросом по LEFT_MARGIN/RIGHT_MARGIN. С корнем - корень с подразделами,
+// без него - разделы верхнего уровня инфоблока
+func (repo *repository) Tree(query TreeQuery) (tree []*TreeNode, errorMessage error) {
+	sqlQuery := "SELECT t.ID, t.CODE, t.XML_ID, t.NAME, t.IBLOCK_SECTION_ID, t.ACTIVE, t.SORT, t.DEPTH_LEVEL, t.LEFT_MARGIN, t.RIGHT_MARGIN" +
+		" FROM `b_iblock_section` t"
+	var args []interface{}
+	rootDepth := "0"
+	if query.RootID > 0 {
+		sqlQuery += " INNER JOIN `b_iblock_section` r ON r.ID = ? AND r.IBLOCK_ID = t.IBLOCK_ID" +
+			" AND t.LEFT_MARGIN >= r.LEFT_MARGIN AND t.RIGHT_MARGIN <= r.RIGHT_MARGIN"
+		args = append(args, query.RootID)
+		rootDepth = "r.DEPTH_LEVEL"
+	}
+
+	var conditions []string
+	if query.IblockID > 0 {
+		conditions = append(conditions, "t.IBLOCK_ID = ?")
+		args = append(args, query.IblockID)
+	}
+	if query.Depth > 0 {
+		conditions = append(conditions, "t.DEPTH_LEVEL <= "+rootDepth+" + ?")
+		args = append(args, query.Depth)
+	}
+	if query.ActiveOnly {
+		conditions = append(conditions, "t.GLOBAL_ACTIVE = 'Y'")
+	}
+	if len(conditions) > 0 {
+		sqlQuery += " WHERE " + strings.Join(conditions, " AND ")
+	}
+	sqlQuery += " ORDER BY t.LEFT_MARGIN"
+
+	var nodes []*TreeNode
+	errorMessage = repo.conn.Select(&nodes, sqlQuery, args...)
+	if errorMessage != nil {
+		return
+	}
+
+	tree = buildTree(nodes)
+
+	return
+}
+
+// buildTree - вложенное дерево из разделов, отсортированных по LEFT_MARGIN: раздел - потомок
+// ближайшего открытого раздела, чей RIGHT_MARGIN больше его LEFT_MARGIN
+func buildTree(nodes []*TreeNode) []*TreeNode {
+	tree := []*TreeNode{}
+	var parents []*TreeNode
+	for _, node := range nodes {
+		node.Children = []*TreeNode{}
+		for len(parents) > 0 && parents[len(parents)-1].RightMargin < node.LeftMargin {
+			parents = parents[:len(parents)-1]
+		}
+
+		if len(parents) == 0 {
+			tree = append(tree, node)
+		} else {
+			parent := parents[len(parents)-1]
+			parent.Children = append(parent.Children, node)
+		}
+		parents = append(parents, node)
+	}
+
+	return tree
+}
@@ -0,0 +1,122 @@
+package section
+
+import (
+	"net/url"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+// testSections - дерево с фиксированными границами, отсортированное по LEFT_MARGIN:
+//
+//	1 [1, 10]
+//		2 [2, 5]
+//			4 [3, 4]
+//		3 [6, 9]
+//			5 [7, 8]
+//	6 [11, 14]
+//		7 [12, 13]
+//	8 [15, 16]
+var testSections = []struct {
+	id, depth, left, right uint64
+}{
+	{1, 1, 1, 10},
+	{2, 2, 2, 5},
+	{4, 3, 3, 4},
+	{3, 2, 6, 9},
+	{5, 3, 7, 8},
+	{6, 1, 11, 14},
+	{7, 2, 12, 13},
+	{8, 1, 15, 16},
+}
+
+// testNodes - разделы с ID из ids в порядке LEFT_MARGIN, как их отдает запрос дерева
+func testNodes(ids ...uint64) (nodes []*TreeNode) {
+	for _, section := range testSections {
+		for _, id := range ids {
+			if section.id == id {
+				nodes = append(nodes, &TreeNode{ID: section.id, DepthLevel: section.depth,
+					LeftMargin: section.left, RightMargin: section.right})
+			}
+		}
+	}
+
+	return
+}
+
+// treeString - дерево в виде "1(2(4) 3) 6"
+func treeString(tree []*TreeNode) string {
+	parts := make([]string, 0, len(tree))
+	for _, node := range tree {
+		part := strconv.FormatUint(node.ID, 10)
+		if node.Children == nil {
+			part += "<nil>"
+		}
+		if len(node.Children) > 0 {
+			part += "(" + treeString(node.Children) + ")"
+		}
+		parts = append(parts, part)
+	}
+
+	return strings.Join(parts, " ")
+}
+
+func TestBuildTree(t *testing.T) {
+	tests := []struct {
+		name string
+		ids  []uint64
+		want string
+	}{
+		{name: "whole iblock", ids: []uint64{1, 2, 3, 4, 5, 6, 7, 8}, want: "1(2(4) 3(5)) 6(7) 8"},
+		{name: "depth 1", ids: []uint64{1, 6, 8}, want: "1 6 8"},
+		{name: "depth 2", ids: []uint64{1, 2, 3, 6, 7, 8}, want: "1(2 3) 6(7) 8"},
+		{name: "root", ids: []uint64{1, 2, 3, 4, 5}, want: "1(2(4) 3(5))"},
+		{name: "root with depth", ids: []uint64{1, 2, 3}, want: "1(2 3)"},
+		{name: "leaf root", ids: []uint64{7}, want: "7"},
+		{name: "orphans without ancestors", ids: []uint64{2, 4, 3, 5, 7}, want: "2(4) 3(5) 7"},
+		{name: "orphan under grandparent", ids: []uint64{1, 4, 3, 5}, want: "1(4 3(5))"},
+		{name: "empty", want: ""},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			tree := buildTree(testNodes(test.ids...))
+			if tree == nil {
+				t.Fatal("buildTree returned nil, want empty list for json")
+			}
+			if got := treeString(tree); got != test.want {
+				t.Errorf("buildTree(%v) = %q, want %q", test.ids, got, test.want)
+			}
+		})
+	}
+}
+
+func TestParseTreeQuery(t *testing.T) {
+	query, err := ParseTreeQuery(url.Values{"iblock_id": {"2"}, "root": {"10"}, "depth": {"3"}, "active": {"Y"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if query != (TreeQuery{IblockID: 2, RootID: 10, Depth: 3, ActiveOnly: true}) {
+		t.Errorf("ParseTreeQuery = %+v", query)
+	}
+
+	query, err = ParseTreeQuery(url.Values{"root": {"10"}, "active": {"N"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if query != (TreeQuery{RootID: 10}) {
+		t.Errorf("ParseTreeQuery = %+v", query)
+	}
+
+	for _, values := range []url.Values{
+		{},
+		{"depth": {"2"}},
+		{"iblock_id": {"abc"}},
+		{"iblock_id": {"-1"}},
+		{"root": {"1"}, "depth": {"1.5"}},
+	} {
+		if _, err = ParseTreeQuery(values); err == nil {
+			t.Errorf("ParseTreeQuery(%v) want error", values)
+		}
+	}
+}
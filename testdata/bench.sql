@@ -0,0 +1,135 @@
+-- Схема битрикса с данными для бенчмарков element и section: сайт s1, инфоблок 1, 10 разделов верхнего уровня
+-- по 4 подраздела, 5000 элементов с метой и свойствами. Выполняется в пустой базе bitrix_bench (см. fixture_test.go)
+
+CREATE TABLE b_lang (LID CHAR(2) NOT NULL PRIMARY KEY, DIR VARCHAR(50) NOT NULL, SERVER_NAME VARCHAR(255));
+
+CREATE TABLE b_iblock (ID INT NOT NULL PRIMARY KEY, CODE VARCHAR(50), IBLOCK_TYPE_ID VARCHAR(50) NOT NULL,
+	XML_ID VARCHAR(255), DETAIL_PAGE_URL VARCHAR(255), SECTION_PAGE_URL VARCHAR(255), LIST_PAGE_URL VARCHAR(255),
+	VERSION INT NOT NULL DEFAULT 1);
+
+CREATE TABLE b_catalog_iblock (IBLOCK_ID INT NOT NULL PRIMARY KEY, PRODUCT_IBLOCK_ID INT NOT NULL DEFAULT 0,
+	SKU_PROPERTY_ID INT NOT NULL DEFAULT 0);
+
+CREATE TABLE b_file (ID INT NOT NULL PRIMARY KEY, SUBDIR VARCHAR(255), FILE_NAME VARCHAR(255) NOT NULL,
+	ORIGINAL_NAME VARCHAR(255), CONTENT_TYPE VARCHAR(255), FILE_SIZE BIGINT, WIDTH INT, HEIGHT INT, DESCRIPTION VARCHAR(255));
+
+CREATE TABLE b_iblock_section (ID INT NOT NULL PRIMARY KEY, IBLOCK_ID INT NOT NULL, IBLOCK_SECTION_ID INT,
+	CODE VARCHAR(255), XML_ID VARCHAR(255), NAME VARCHAR(255) NOT NULL, ACTIVE CHAR(1) NOT NULL DEFAULT 'Y',
+	GLOBAL_ACTIVE CHAR(1) NOT NULL DEFAULT 'Y', SORT INT NOT NULL DEFAULT 500, DEPTH_LEVEL INT,
+	LEFT_MARGIN INT, RIGHT_MARGIN INT, PICTURE INT, DESCRIPTION TEXT, SEARCHABLE_CONTENT TEXT,
+	DATE_CREATE DATETIME, CREATED_BY INT NOT NULL DEFAULT 1, TIMESTAMP_X DATETIME, MODIFIED_BY INT,
+	KEY ix_iblock_margin (IBLOCK_ID, LEFT_MARGIN, RIGHT_MARGIN));
+
+CREATE TABLE b_iblock_element (ID INT NOT NULL PRIMARY KEY, IBLOCK_ID INT NOT NULL, IBLOCK_SECTION_ID INT,
+	CODE VARCHAR(255), XML_ID VARCHAR(255), NAME VARCHAR(255) NOT NULL, ACTIVE CHAR(1) NOT NULL DEFAULT 'Y',
+	ACTIVE_FROM DATETIME, ACTIVE_TO DATETIME, SORT INT NOT NULL DEFAULT 500, PREVIEW_PICTURE INT, PREVIEW_TEXT TEXT,
+	DETAIL_PICTURE INT, DETAIL_TEXT TEXT, SEARCHABLE_CONTENT TEXT, DATE_CREATE DATETIME, CREATED_BY INT NOT NULL DEFAULT 1,
+	TIMESTAMP_X DATETIME, MODIFIED_BY INT, SHOW_COUNTER INT,
+	KEY ix_iblock_sort (IBLOCK_ID, SORT, ID));
+
+CREATE TABLE b_iblock_section_element (IBLOCK_SECTION_ID INT NOT NULL, IBLOCK_ELEMENT_ID INT NOT NULL,
+	PRIMARY KEY (IBLOCK_SECTION_ID, IBLOCK_ELEMENT_ID));
+
+CREATE TABLE b_iblock_iproperty (ID INT NOT NULL PRIMARY KEY, IBLOCK_ID INT NOT NULL, CODE VARCHAR(50) NOT NULL);
+
+CREATE TABLE b_iblock_element_iprop (IBLOCK_ID INT NOT NULL, SECTION_ID INT NOT NULL, ELEMENT_ID INT NOT NULL,
+	IPROP_ID INT NOT NULL, VALUE TEXT NOT NULL, PRIMARY KEY (ELEMENT_ID, IPROP_ID));
+
+CREATE TABLE b_iblock_section_iprop (IBLOCK_ID INT NOT NULL, SECTION_ID INT NOT NULL, IPROP_ID INT NOT NULL,
+	VALUE TEXT NOT NULL, PRIMARY KEY (SECTION_ID, IPROP_ID));
+
+CREATE TABLE b_iblock_property (ID INT NOT NULL PRIMARY KEY, IBLOCK_ID INT NOT NULL, CODE VARCHAR(50),
+	NAME VARCHAR(255) NOT NULL, ACTIVE CHAR(1) NOT NULL DEFAULT 'Y', SORT INT NOT NULL DEFAULT 500,
+	PROPERTY_TYPE CHAR(1) NOT NULL DEFAULT 'S', USER_TYPE VARCHAR(255), MULTIPLE CHAR(1) NOT NULL DEFAULT 'N',
+	WITH_DESCRIPTION CHAR(1) DEFAULT 'N');
+
+CREATE TABLE b_iblock_property_enum (ID INT NOT NULL PRIMARY KEY, PROPERTY_ID INT NOT NULL, VALUE VARCHAR(255) NOT NULL,
+	XML_ID VARCHAR(200) NOT NULL, SORT INT NOT NULL DEFAULT 500);
+
+CREATE TABLE b_iblock_element_property (ID INT NOT NULL AUTO_INCREMENT PRIMARY KEY, IBLOCK_PROPERTY_ID INT NOT NULL,
+	IBLOCK_ELEMENT_ID INT NOT NULL, VALUE TEXT NOT NULL, VALUE_TYPE CHAR(4) NOT NULL DEFAULT 'text', VALUE_ENUM INT,
+	VALUE_NUM DECIMAL(18,4), DESCRIPTION VARCHAR(255), KEY ix_element (IBLOCK_ELEMENT_ID, IBLOCK_PROPERTY_ID));
+
+CREATE TABLE b_uts_iblock_1_section (VALUE_ID INT NOT NULL PRIMARY KEY, UF_ALT_LINK TEXT, UF_BANNER TEXT, UF_CODE TEXT,
+	UF_COMBUSTION TEXT, UF_DESCRIPTION_ARCH TEXT, UF_DESCRIPTION_B2B TEXT, UF_EMAIL_TO TEXT, UF_H1_ARCH TEXT, UF_H1_B2B TEXT,
+	UF_KEYWORDS_ARCH TEXT, UF_KEYWORDS_B2B TEXT, UF_MAKE_OFFER_BETTER TEXT, UF_PROMO_END TEXT, UF_PROMO_START TEXT,
+	UF_PROPERTY_CODE TEXT, UF_RULES_FILE TEXT, UF_TITLE_ARCH TEXT, UF_TITLE_B2B TEXT);
+
+-- числа 1..10000 для генерации строк
+CREATE TABLE bench_digits (D INT NOT NULL PRIMARY KEY);
+INSERT INTO bench_digits (D) VALUES (0), (1), (2), (3), (4), (5), (6), (7), (8), (9);
+CREATE TABLE bench_numbers (N INT NOT NULL PRIMARY KEY);
+INSERT INTO bench_numbers (N)
+	SELECT a.D + b.D * 10 + c.D * 100 + d.D * 1000 + 1
+	FROM bench_digits a, bench_digits b, bench_digits c, bench_digits d;
+
+INSERT INTO b_lang (LID, DIR, SERVER_NAME) VALUES ('s1', '/', 'shop.example.com');
+
+INSERT INTO b_iblock (ID, CODE, IBLOCK_TYPE_ID, XML_ID, DETAIL_PAGE_URL, SECTION_PAGE_URL, LIST_PAGE_URL) VALUES
+	(1, 'catalog', 'catalog', 'catalog', '#SITE_DIR#/catalog/#SECTION_CODE_PATH#/#ELEMENT_CODE#/',
+	'#SITE_DIR#/catalog/#SECTION_CODE_PATH#/', '#SITE_DIR#/catalog/');
+
+INSERT INTO b_iblock_iproperty (ID, IBLOCK_ID, CODE) VALUES (1, 1, 'ELEMENT_META_TITLE'),
+	(2, 1, 'ELEMENT_META_DESCRIPTION'), (3, 1, 'SECTION_META_TITLE'), (4, 1, 'SECTION_META_DESCRIPTION');
+
+INSERT INTO b_iblock_property (ID, IBLOCK_ID, CODE, NAME, SORT, PROPERTY_TYPE, MULTIPLE) VALUES
+	(1, 1, 'BRAND', 'Бренд', 100, 'S', 'N'), (2, 1, 'WEIGHT', 'Вес', 200, 'N', 'N'),
+	(3, 1, 'COLOR', 'Цвет', 300, 'L', 'N'), (4, 1, 'TAGS', 'Теги', 400, 'S', 'Y');
+
+INSERT INTO b_iblock_property_enum (ID, PROPERTY_ID, VALUE, XML_ID, SORT) VALUES
+	(1, 3, 'Белый', 'white', 100), (2, 3, 'Черный', 'black', 200), (3, 3, 'Дуб', 'oak', 300);
+
+-- дерево разделов: раздел верхнего уровня T (ID 1, 6, 11...) и за ним 4 подраздела S, границы по порядку обхода
+INSERT INTO b_iblock_section (ID, IBLOCK_ID, IBLOCK_SECTION_ID, CODE, XML_ID, NAME, SORT, DEPTH_LEVEL,
+	LEFT_MARGIN, RIGHT_MARGIN, DESCRIPTION, DATE_CREATE, TIMESTAMP_X)
+	SELECT n.N, 1, IF(n.S = 0, NULL, n.N - n.S), CONCAT('section-', n.N), CONCAT('xml-', n.N), CONCAT('Раздел ', n.N),
+		n.N * 10, IF(n.S = 0, 1, 2),
+		IF(n.S = 0, (n.T - 1) * 10 + 1, (n.T - 1) * 10 + 2 * n.S),
+		IF(n.S = 0, (n.T - 1) * 10 + 10, (n.T - 1) * 10 + 2 * n.S + 1),
+		CONCAT('Описание раздела ', n.N), NOW(), NOW()
+	FROM (SELECT N, (N - 1) DIV 5 + 1 AS T, (N - 1) MOD 5 AS S FROM bench_numbers WHERE N <= 50) n;
+
+INSERT INTO b_iblock_section_iprop (IBLOCK_ID, SECTION_ID, IPROP_ID, VALUE)
+	SELECT 1, s.ID, p.ID, CONCAT('Мета ', p.ID, ' раздела ', s.ID)
+	FROM b_iblock_section s, b_iblock_iproperty p WHERE p.ID IN (3, 4);
+
+INSERT INTO b_uts_iblock_1_section (VALUE_ID, UF_ALT_LINK, UF_BANNER, UF_CODE, UF_COMBUSTION, UF_DESCRIPTION_ARCH,
+	UF_DESCRIPTION_B2B, UF_EMAIL_TO, UF_H1_ARCH, UF_H1_B2B, UF_KEYWORDS_ARCH, UF_KEYWORDS_B2B, UF_MAKE_OFFER_BETTER,
+	UF_PROMO_END, UF_PROMO_START, UF_PROPERTY_CODE, UF_RULES_FILE, UF_TITLE_ARCH, UF_TITLE_B2B)
+	SELECT ID, CONCAT('uf_alt_link ', ID), CONCAT('uf_banner ', ID), CONCAT('uf_code ', ID), CONCAT('uf_combustion ', ID),
+		CONCAT('uf_description_arch ', ID), CONCAT('uf_description_b2b ', ID), CONCAT('uf_email_to ', ID),
+		CONCAT('uf_h1_arch ', ID), CONCAT('uf_h1_b2b ', ID), CONCAT('uf_keywords_arch ', ID), CONCAT('uf_keywords_b2b ', ID),
+		CONCAT('uf_make_offer_better ', ID), CONCAT('uf_promo_end ', ID), CONCAT('uf_promo_start ', ID),
+		CONCAT('uf_property_code ', ID), CONCAT('uf_rules_file ', ID), CONCAT('uf_title_arch ', ID), CONCAT('uf_title_b2b ', ID)
+	FROM b_iblock_section;
+
+-- элементы по кругу привязаны к 40 подразделам
+INSERT INTO b_iblock_element (ID, IBLOCK_ID, IBLOCK_SECTION_ID, CODE, XML_ID, NAME, ACTIVE, SORT,
+	PREVIEW_TEXT, DATE_CREATE, TIMESTAMP_X)
+	SELECT N, 1, ((N MOD 40) DIV 4) * 5 + 2 + (N MOD 40) MOD 4, CONCAT('element-', N), CONCAT('xml-', N),
+		CONCAT('Элемент ', N), 'Y', N MOD 100 * 10, CONCAT('Анонс ', N), NOW(), NOW()
+	FROM bench_numbers WHERE N <= 5000;
+
+INSERT INTO b_iblock_element_iprop (IBLOCK_ID, SECTION_ID, ELEMENT_ID, IPROP_ID, VALUE)
+	SELECT 1, 0, ID, 1, CONCAT('Купить элемент ', ID) FROM b_iblock_element;
+
+INSERT INTO b_iblock_element_iprop (IBLOCK_ID, SECTION_ID, ELEMENT_ID, IPROP_ID, VALUE)
+	SELECT 1, 0, ID, 2, CONCAT('Описание элемента ', ID) FROM b_iblock_element;
+
+INSERT INTO b_iblock_section_element (IBLOCK_SECTION_ID, IBLOCK_ELEMENT_ID)
+	SELECT IBLOCK_SECTION_ID, ID FROM b_iblock_element;
+
+INSERT INTO b_iblock_element_property (IBLOCK_PROPERTY_ID, IBLOCK_ELEMENT_ID, VALUE, VALUE_ENUM, VALUE_NUM)
+	SELECT 1, ID, CONCAT('Бренд ', ID MOD 20), NULL, NULL FROM b_iblock_element;
+
+INSERT INTO b_iblock_element_property (IBLOCK_PROPERTY_ID, IBLOCK_ELEMENT_ID, VALUE, VALUE_ENUM, VALUE_NUM)
+	SELECT 2, ID, CONCAT(ID MOD 50, '.5'), NULL, ID MOD 50 + 0.5 FROM b_iblock_element;
+
+INSERT INTO b_iblock_element_property (IBLOCK_PROPERTY_ID, IBLOCK_ELEMENT_ID, VALUE, VALUE_ENUM, VALUE_NUM)
+	SELECT 3, ID, ID MOD 3 + 1, ID MOD 3 + 1, NULL FROM b_iblock_element;
+
+INSERT INTO b_iblock_element_property (IBLOCK_PROPERTY_ID, IBLOCK_ELEMENT_ID, VALUE, VALUE_ENUM, VALUE_NUM)
+	SELECT 4, ID, 'новинка', NULL, NULL FROM b_iblock_element;
+
+INSERT INTO b_iblock_element_property (IBLOCK_PROPERTY_ID, IBLOCK_ELEMENT_ID, VALUE, VALUE_ENUM, VALUE_NUM)
+	SELECT 4, ID, 'хит', NULL, NULL FROM b_iblock_element;